@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Returns the number of chats blocking each topic.
+func (s *State) blockCounts() map[string]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	res := map[string]int{}
+	for _, chat := range s.ChatIds {
+		for topic, blocked := range chat.BlockedTopics {
+			if blocked {
+				res[topic]++
+			}
+		}
+	}
+	return res
+}
+
+// Returns the number of subscribed chats, of the proposals announced since `since` and the time
+// of the last successful poll.
+func (s *State) operatorStats(since time.Time) (subscribers, processed int, lastFetch time.Time) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, a := range s.Activity {
+		if a.Time.After(since) {
+			processed++
+		}
+	}
+	return len(s.ChatIds), processed, s.LastFetch
+}
+
+func statsCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	now := time.Now().UTC()
+	subscribers, processed, lastFetch := r.state.operatorStats(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	lines := []string{
+		fmt.Sprintf("Subscribers: %d", subscribers),
+		fmt.Sprintf("Proposals processed today: %d", processed),
+	}
+	sent, failed, since := metrics.sends()
+	lines = append(lines, fmt.Sprintf("Messages sent since %s: %d, failed: %d", since.UTC().Format(time.RFC1123), sent, failed))
+	if lastFetch.IsZero() {
+		lines = append(lines, "Last poll: never")
+	} else {
+		lines = append(lines, fmt.Sprintf("Last poll: %s (%s ago)", lastFetch.UTC().Format(time.RFC1123), formatCountdown(time.Since(lastFetch))))
+	}
+	counts := r.state.blockCounts()
+	var topics []string
+	for topic := range counts {
+		topics = append(topics, topic)
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if counts[topics[i]] == counts[topics[j]] {
+			return topics[i] < topics[j]
+		}
+		return counts[topics[i]] > counts[topics[j]]
+	})
+	blocked := []string{"Blocked topics:"}
+	for _, topic := range topics {
+		blocked = append(blocked, fmt.Sprintf("%s: %d", topic, counts[topic]))
+	}
+	if len(topics) == 0 {
+		blocked[0] += " none"
+	}
+	return strings.Join(append(lines, strings.Join(blocked, "\n")), "\n")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Creates a new API token for chat `id`, replacing the previous one. Only the hash of the token
+// is stored.
+func (s *State) createAPIToken(id int64) (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base32.StdEncoding.EncodeToString(buf)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return "", fmt.Errorf("chat %d is not subscribed", id)
+	}
+	chat.APITokenHash = hashToken(token)
+	return token, nil
+}
+
+func (s *State) revokeAPIToken(id int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.APITokenHash = ""
+	return true
+}
+
+// Returns the id of the chat `token` was created for.
+func (s *State) chatForToken(token string) (int64, bool) {
+	if token == "" {
+		return 0, false
+	}
+	hash := hashToken(token)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for id, chat := range s.ChatIds {
+		if chat.APITokenHash == hash {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Returns a copy of the delivery records of chat `id`.
+func (s *State) deliveryHistory(id int64) ([]Delivery, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return nil, false
+	}
+	res := []Delivery{}
+	for _, d := range chat.Deliveries {
+		res = append(res, *d)
+	}
+	return res, true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Filters of a chat as exposed by the REST API.
+type apiFilters struct {
+	FilterMode      string   `json:"filter_mode,omitempty"`
+	BlockedTopics   []string `json:"blocked_topics"`
+	OnlyTopics      []string `json:"only_topics,omitempty"`
+	Keywords        []string `json:"keywords,omitempty"`
+	BlockedKeywords []string `json:"blocked_keywords,omitempty"`
+}
+
+// Authenticates the request with the "Authorization: Bearer <token>" header and returns the id of
+// the chat the token grants access to.
+func authenticate(state *State, w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, ok := state.chatForToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if !ok {
+		http.Error(w, "invalid API token", http.StatusUnauthorized)
+	}
+	return id, ok
+}
+
+func writeFilters(w http.ResponseWriter, state *State, id int64) {
+	chat, ok := state.chat(id)
+	if !ok {
+		http.Error(w, NOT_SUBSCRIBED, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiFilters{
+		FilterMode:      chat.FilterMode,
+		BlockedTopics:   append([]string{}, sortedKeys(chat.BlockedTopics)...),
+		OnlyTopics:      sortedKeys(chat.OnlyTopics),
+		Keywords:        chat.Keywords,
+		BlockedKeywords: chat.BlockedKeywords,
+	})
+}
+
+// Registers the REST API scoped to the chat of the API token:
+//
+//	GET /api/chat                           filters of the chat
+//	PUT|DELETE /api/chat/blocked_topics/<t> blocks or unblocks a topic
+//	PUT|DELETE /api/chat/keywords/<k>       adds or removes a keyword
+//	GET /api/chat/history                   delivery records of the recent notifications
+func serveAPI(state *State) {
+	http.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := authenticate(state, w, r); ok {
+			writeFilters(w, state, id)
+		}
+	})
+	http.HandleFunc("/api/chat/history", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authenticate(state, w, r)
+		if !ok {
+			return
+		}
+		history, ok := state.deliveryHistory(id)
+		if !ok {
+			http.Error(w, NOT_SUBSCRIBED, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
+	})
+	http.HandleFunc("/api/chat/", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authenticate(state, w, r)
+		if !ok {
+			return
+		}
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/chat/"), "/", 2)
+		if len(parts) != 2 || parts[1] == "" || (r.Method != http.MethodPut && r.Method != http.MethodDelete) {
+			http.NotFound(w, r)
+			return
+		}
+		add := r.Method == http.MethodPut
+		switch parts[0] {
+		case "blocked_topics":
+			if add {
+				state.blockTopic(id, parts[1])
+			} else {
+				state.unblockTopic(id, parts[1])
+			}
+		case "keywords":
+			if !add {
+				state.removeKeyword(id, parts[1], false)
+			} else if err := state.addKeyword(id, parts[1], false); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		writeFilters(w, state, id)
+	})
+}
+
+func apiTokenCommand(r *Request) string {
+	if !r.message.Chat.IsPrivate() {
+		return "API tokens are only available in private chats with the bot."
+	}
+	if len(r.args) == 1 && r.args[0] == "revoke" {
+		if !r.state.revokeAPIToken(r.id) {
+			return NOT_SUBSCRIBED
+		}
+		return "Your API token was revoked."
+	}
+	if len(r.args) > 0 {
+		return "Please use /apitoken or /apitoken revoke"
+	}
+	if HTTP_ADDR == "" {
+		return "The REST API is not enabled on this bot."
+	}
+	token, err := r.state.createAPIToken(r.id)
+	if err != nil {
+		return NOT_SUBSCRIBED
+	}
+	return fmt.Sprintf("Your API token, replacing any previous one: %s\n\nIt grants access to the filters and the "+
+		"notification history of this chat only; send it as \"Authorization: Bearer <token>\" to /api/chat. "+
+		"Use /apitoken revoke to revoke it.", token)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram user ids of the community verifiers allowed to attest that they reproduced the build
+// of an upgrade proposal.
+var VERIFIER_IDS = getEnvInts("VERIFIER_IDS")
+
+// Topics of the proposals upgrading the IC, whose builds can be attested.
+var UPGRADE_TOPICS = map[string]bool{
+	"IcOsVersionElection": true, "ProtocolCanisterManagement": true, "NetworkCanisterManagement": true,
+	"ServiceNervousSystemManagement": true,
+}
+
+type Attestation struct {
+	Verifier string    `json:"verifier"`
+	Time     time.Time `json:"time"`
+}
+
+// Records the attestation of `verifier` for `proposalId`; returns false if they already attested it.
+func (s *State) attest(proposalId uint64, verifier string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, a := range s.Attestations[proposalId] {
+		if a.Verifier == verifier {
+			return false
+		}
+	}
+	s.Attestations[proposalId] = append(s.Attestations[proposalId], Attestation{verifier, time.Now()})
+	return true
+}
+
+func (s *State) attestations(proposalId uint64) []Attestation {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]Attestation(nil), s.Attestations[proposalId]...)
+}
+
+// Drops the attestations of proposals which aren't cached anymore. Expects the lock to be held.
+func (s *State) pruneAttestations() {
+	cached := map[uint64]bool{}
+	for _, p := range s.Recent {
+		cached[p.Id] = true
+	}
+	for id := range s.Attestations {
+		if !cached[id] {
+			delete(s.Attestations, id)
+		}
+	}
+}
+
+func isVerifier(userId int64) bool {
+	for _, id := range VERIFIER_IDS {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// Renders the names of the verifiers escaped for the HTML parse mode, as they come from users.
+func renderAttestations(attestations []Attestation) string {
+	var names []string
+	for _, a := range attestations {
+		names = append(names, html.EscapeString(a.Verifier))
+	}
+	return "✅ Build reproduced by " + strings.Join(names, ", ")
+}
+
+// Renders a notification which is edited after its delivery, with the attestations, the result of
+// the artifact verification and, if the chat enabled it, the live tally.
+func renderEdited(state *State, proposal Proposal, chat Chat) string {
+	text := renderProposal(proposal, chat)
+	if attestations := state.attestations(proposal.Id); len(attestations) > 0 && proposal.Source == "" {
+		text += "\n\n" + renderAttestations(attestations)
+	}
+	if chat.LiveTally && proposal.Tally != nil {
+		text += "\n\n📊 " + formatTally(*proposal.Tally)
+	}
+	return text
+}
+
+// Edits the notifications about `proposal` to show its attestations and the result of the
+// artifact verification. Digests and other lists of proposals are left as they are.
+func annotateNotifications(shards *Shards, state *State, proposal Proposal) {
+	edited := 0
+	for id, messageId := range state.recipients(proposal) {
+		chat, ok := state.chat(id)
+		if !ok {
+			continue
+		}
+		text := renderEdited(state, proposal, chat)
+		if len(text) > MAX_MESSAGE_LENGTH {
+			continue
+		}
+		edit := tgbotapi.NewEditMessageText(id, messageId, text)
+		edit.ParseMode = tgbotapi.ModeHTML
+		edit.DisableWebPagePreview = true
+		if markup, ok := notificationKeyboard(state, proposal, chat); ok {
+			edit.ReplyMarkup = &markup
+		}
+		if err := editMessage(shards, edit); err != nil {
+			log.Println("Couldn't annotate the notification about proposal", proposal.Id, "in", id, ":", err)
+			continue
+		}
+		edited++
+	}
+	log.Println("Annotated", edited, "notifications about proposal", proposal.Id)
+}
+
+// Lets verifiers attest an upgrade proposal; in the admin chat, the name of the verifier is given
+// explicitly to approve an attestation submitted elsewhere.
+func attestCommand(r *Request) string {
+	from := r.message.From
+	admin := ADMIN_CHAT_ID != 0 && r.id == ADMIN_CHAT_ID
+	if !admin && (from == nil || !isVerifier(from.ID)) {
+		return "Only the configured verifiers can attest proposals."
+	}
+	usage := "Please use /attest <proposal id>"
+	if admin {
+		usage = "Please use /attest <proposal id> <verifier>"
+	}
+	if len(r.args) == 0 || (admin && len(r.args) != 2) || (!admin && len(r.args) != 1) {
+		return usage
+	}
+	proposalId, err := strconv.ParseUint(strings.TrimPrefix(r.args[0], "#"), 10, 64)
+	if err != nil {
+		return usage
+	}
+	proposal, ok := r.state.cachedProposal(proposalId)
+	if !ok {
+		return fmt.Sprintf("Proposal %d is not among the recent proposals.", proposalId)
+	}
+	if !UPGRADE_TOPICS[proposal.Topic] {
+		return fmt.Sprintf("Proposal %d is no upgrade proposal.", proposalId)
+	}
+	verifier := ""
+	switch {
+	case admin:
+		verifier = r.args[1]
+	case from.UserName != "":
+		verifier = "@" + from.UserName
+	default:
+		verifier = from.FirstName
+	}
+	if !r.state.attest(proposalId, verifier) {
+		return fmt.Sprintf("%s already attested proposal %d.", verifier, proposalId)
+	}
+	go annotateNotifications(r.shards, r.state, proposal)
+	return fmt.Sprintf("Thanks! The notifications about proposal %d will show that %s reproduced the build.", proposalId, verifier)
+}
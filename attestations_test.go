@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestRenderAttestations(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifiers []string
+		want      string
+	}{
+		{"one", []string{"@alice"}, "✅ Build reproduced by @alice"},
+		{"several", []string{"Alice", "@bob"}, "✅ Build reproduced by Alice, @bob"},
+		{"html", []string{"<b>Eve</b> & co"}, "✅ Build reproduced by &lt;b&gt;Eve&lt;/b&gt; &amp; co"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attestations []Attestation
+			for _, v := range tt.verifiers {
+				attestations = append(attestations, Attestation{Verifier: v})
+			}
+			if got := renderAttestations(attestations); got != tt.want {
+				t.Errorf("renderAttestations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage canister on the Internet Computer the state is backed up to, e.g.
+// `https://<canister id>.raw.icp0.io`. The canister serves its snapshots over the HTTP gateway:
+// `PUT /snapshots/<unix time>` stores one (upgraded to an update call by `http_request`) and
+// `GET /snapshots/latest` returns the newest. Only encrypted snapshots leave the host, so the
+// canister never sees the state.
+var (
+	BACKUP_CANISTER_URL = ""
+	// Random 32-byte AES key, base64-encoded, the snapshots are encrypted with; e.g. generated with
+	// `openssl rand -base64 32`.
+	BACKUP_KEY = os.Getenv("BACKUP_KEY")
+	// Bearer token the canister accepts writes with.
+	BACKUP_TOKEN    = os.Getenv("BACKUP_TOKEN")
+	BACKUP_INTERVAL = 24 * time.Hour
+)
+
+// Ingress messages to a canister are limited to 2 MiB, headers included.
+const MAX_BACKUP_SIZE = 2000000
+
+var backupClient = http.Client{Timeout: time.Minute}
+
+// Decodes BACKUP_KEY. Passphrases are rejected, as they would make the uploaded snapshots cheap
+// to brute-force.
+func backupKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(BACKUP_KEY))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("expected 32 random bytes in base64, e.g. from `openssl rand -base64 32`")
+	}
+	return key, nil
+}
+
+// Returns the AES-256-GCM cipher with BACKUP_KEY.
+func backupCipher() (cipher.AEAD, error) {
+	key, err := backupKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Compresses and encrypts a snapshot; the nonce is prepended to the ciphertext.
+func encryptSnapshot(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	aead, err := backupCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, compressed.Bytes(), nil), nil
+}
+
+func decryptSnapshot(data []byte) ([]byte, error) {
+	aead, err := backupCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("snapshot too short")
+	}
+	compressed, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong BACKUP_KEY or corrupted snapshot")
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// Serializes the state like it is persisted.
+func (s *State) snapshot() ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return json.Marshal(s)
+}
+
+// Uploads an encrypted snapshot of the state to the storage canister.
+func backupState(state *State) {
+	data, err := state.snapshot()
+	if err != nil {
+		log.Println("Couldn't serialize the state for the backup:", err)
+		return
+	}
+	encrypted, err := encryptSnapshot(data)
+	if err != nil {
+		log.Println("Couldn't encrypt the backup:", err)
+		return
+	}
+	if len(encrypted) > MAX_BACKUP_SIZE {
+		log.Println("Couldn't back up the state: the snapshot has", len(encrypted), "bytes, at most", MAX_BACKUP_SIZE, "are accepted by the canister")
+		return
+	}
+	url := fmt.Sprintf("%s/snapshots/%d", BACKUP_CANISTER_URL, time.Now().Unix())
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(encrypted))
+	if err != nil {
+		log.Println("Couldn't create the backup request:", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if BACKUP_TOKEN != "" {
+		req.Header.Set("Authorization", "Bearer "+BACKUP_TOKEN)
+	}
+	resp, err := backupClient.Do(req)
+	if err != nil {
+		log.Println("Couldn't upload the backup:", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Println("Couldn't upload the backup: status", resp.Status)
+		return
+	}
+	log.Println(len(encrypted), "bytes backed up to", BACKUP_CANISTER_URL)
+}
+
+// Downloads the newest snapshot from the storage canister and writes the decrypted state to `w`.
+func fetchBackup(w io.Writer) error {
+	resp, err := backupClient.Get(BACKUP_CANISTER_URL + "/snapshots/latest")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	encrypted, err := ioutil.ReadAll(io.LimitReader(resp.Body, MAX_BACKUP_SIZE+1))
+	if err != nil {
+		return err
+	}
+	data, err := decryptSnapshot(encrypted)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("the snapshot isn't a valid state")
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func runRestoreBackup() {
+	if BACKUP_CANISTER_URL == "" {
+		log.Fatalln("Usage: BACKUP_CANISTER_URL=... BACKUP_KEY=... nns-proposals-bot restore-backup > state.json")
+	}
+	if err := fetchBackup(os.Stdout); err != nil {
+		log.Fatalln("Couldn't restore the backup from", BACKUP_CANISTER_URL, ":", err)
+	}
+}
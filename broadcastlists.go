@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var listNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Named list of chats managed by the operator, e.g. all chats of an organization. The filters of
+// the list are copied to every member chat whenever they change or a chat joins the list.
+type BroadcastList struct {
+	ChatIds       []int64         `json:"chat_ids"`
+	BlockedTopics map[string]bool `json:"blocked_topics,omitempty"`
+	OnlyTopics    map[string]bool `json:"only_topics,omitempty"`
+	Keywords      []string        `json:"keywords,omitempty"`
+}
+
+// Applies the filters of `list` to `chat`. Expects the lock to be held.
+func (list *BroadcastList) applyTo(chat *Chat) {
+	chat.BlockedTopics = map[string]bool{}
+	for topic, blocked := range list.BlockedTopics {
+		chat.BlockedTopics[topic] = blocked
+	}
+	chat.FilterMode, chat.OnlyTopics = "", nil
+	if len(list.OnlyTopics) > 0 {
+		chat.FilterMode, chat.OnlyTopics = FILTER_ONLY, map[string]bool{}
+		for topic := range list.OnlyTopics {
+			chat.OnlyTopics[topic] = true
+		}
+	}
+	chat.Keywords = append([]string(nil), list.Keywords...)
+}
+
+// Adds the subscribed chats among `ids` to the list `name`, which is created if needed, and returns
+// the ids of the chats which aren't subscribed. A chat belongs to at most one list.
+func (s *State) addToList(name string, ids []int64) (missing []int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	list := s.BroadcastLists[name]
+	if list == nil {
+		list = &BroadcastList{}
+		s.BroadcastLists[name] = list
+	}
+	for _, id := range ids {
+		chat := s.ChatIds[id]
+		if chat == nil {
+			missing = append(missing, id)
+			continue
+		}
+		if chat.BroadcastList == name {
+			continue
+		}
+		if previous := s.BroadcastLists[chat.BroadcastList]; previous != nil {
+			previous.ChatIds = withoutChat(previous.ChatIds, id)
+		}
+		chat.BroadcastList = name
+		list.ChatIds = append(list.ChatIds, id)
+		list.applyTo(chat)
+	}
+	return
+}
+
+func withoutChat(ids []int64, id int64) (res []int64) {
+	for _, other := range ids {
+		if other != id {
+			res = append(res, other)
+		}
+	}
+	return
+}
+
+// Removes `ids` from the list `name`; the chats keep their filters.
+func (s *State) removeFromList(name string, ids []int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	list := s.BroadcastLists[name]
+	if list == nil {
+		return false
+	}
+	for _, id := range ids {
+		list.ChatIds = withoutChat(list.ChatIds, id)
+		if chat := s.ChatIds[id]; chat != nil && chat.BroadcastList == name {
+			chat.BroadcastList = ""
+		}
+	}
+	return true
+}
+
+// Deletes the list `name`; its chats keep their filters.
+func (s *State) deleteList(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	list := s.BroadcastLists[name]
+	if list == nil {
+		return false
+	}
+	for _, id := range list.ChatIds {
+		if chat := s.ChatIds[id]; chat != nil && chat.BroadcastList == name {
+			chat.BroadcastList = ""
+		}
+	}
+	delete(s.BroadcastLists, name)
+	return true
+}
+
+// Changes the filters of the list `name` and applies them to all its chats. Returns the number of
+// updated chats or an error if the list doesn't exist or `update` fails.
+func (s *State) updateList(name string, update func(list *BroadcastList) error) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	list := s.BroadcastLists[name]
+	if list == nil {
+		return 0, fmt.Errorf("there is no list %s", name)
+	}
+	if err := update(list); err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, id := range list.ChatIds {
+		if chat := s.ChatIds[id]; chat != nil {
+			list.applyTo(chat)
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// Returns the ids of the subscribed chats of the list `name`.
+func (s *State) listMembers(name string) (res []int64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if list := s.BroadcastLists[name]; list != nil {
+		for _, id := range list.ChatIds {
+			if s.ChatIds[id] != nil {
+				res = append(res, id)
+			}
+		}
+	}
+	return
+}
+
+// Renders all lists with their chats and filters.
+func (s *State) describeLists() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.BroadcastLists) == 0 {
+		return "There are no broadcast lists yet; create one with /list <name> add <chat id>."
+	}
+	var names []string
+	for name := range s.BroadcastLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		list := s.BroadcastLists[name]
+		var chats []string
+		for _, id := range list.ChatIds {
+			chats = append(chats, fmt.Sprint(id))
+		}
+		filters := "all topics"
+		if len(list.OnlyTopics) > 0 {
+			filters = "only " + strings.Join(sortedKeys(list.OnlyTopics), ", ")
+		}
+		if blocked := sortedKeys(list.BlockedTopics); len(blocked) > 0 {
+			filters += ", blocked " + strings.Join(blocked, ", ")
+		}
+		if len(list.Keywords) > 0 {
+			filters += ", keywords " + strings.Join(list.Keywords, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d chats: %s)\nFilters: %s", name, len(list.ChatIds), strings.Join(chats, ", "), filters))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func parseChatIds(args []string) ([]int64, bool) {
+	var res []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		res = append(res, id)
+	}
+	return res, len(res) > 0
+}
+
+// Sends `text` to all chats of the list `name` and returns the number of chats it reached.
+func broadcast(shards *Shards, state *State, name, text string) int {
+	sent := 0
+	for _, id := range state.listMembers(name) {
+		if _, err := send(shards, state, tgbotapi.NewMessage(id, text)); err == nil {
+			sent++
+		}
+	}
+	log.Println("Broadcast a message to", sent, "chats of the list", name)
+	return sent
+}
+
+const LIST_USAGE = "Please use /list <name> add|remove <chat id>..., /list <name> block|unblock <topic>..., " +
+	"/list <name> only <topic>...|off, /list <name> keywords <keyword>...|off, /list <name> send <text> or /list <name> delete"
+
+// Manages the broadcast lists; only available in the admin chat.
+func listCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	if len(r.args) == 0 {
+		return r.state.describeLists()
+	}
+	if len(r.args) < 2 || !listNamePattern.MatchString(r.args[0]) {
+		return LIST_USAGE
+	}
+	name, action, args := r.args[0], r.args[1], r.args[2:]
+	var updated int
+	var err error
+	switch action {
+	case "add":
+		ids, ok := parseChatIds(args)
+		if !ok {
+			return LIST_USAGE
+		}
+		missing := r.state.addToList(name, ids)
+		for _, id := range ids {
+			refreshPinnedSettings(r.shards.botFor(id), r.state, id)
+		}
+		if len(missing) > 0 {
+			return fmt.Sprintf("Added the chats to %s, except %v, which aren't subscribed.", name, missing)
+		}
+		return fmt.Sprintf("Added the chats to %s; they have the filters of the list now.", name)
+	case "remove":
+		ids, ok := parseChatIds(args)
+		if !ok {
+			return LIST_USAGE
+		}
+		if !r.state.removeFromList(name, ids) {
+			return fmt.Sprintf("There is no list %s.", name)
+		}
+		return fmt.Sprintf("Removed the chats from %s; they keep their filters.", name)
+	case "delete":
+		if !r.state.deleteList(name) {
+			return fmt.Sprintf("There is no list %s.", name)
+		}
+		return fmt.Sprintf("Deleted the list %s; its chats keep their filters.", name)
+	case "send":
+		text := strings.TrimSpace(r.message.Text)
+		if i := strings.Index(text, " send "); i >= 0 && strings.TrimSpace(text[i+6:]) != "" {
+			return fmt.Sprintf("Sent the message to %d chats of %s.", broadcast(r.shards, r.state, name, strings.TrimSpace(text[i+6:])), name)
+		}
+		return LIST_USAGE
+	case "block", "unblock":
+		if len(args) == 0 {
+			return LIST_USAGE
+		}
+		updated, err = r.state.updateList(name, func(list *BroadcastList) error {
+			blocked := map[string]bool{}
+			for topic := range list.BlockedTopics {
+				blocked[topic] = true
+			}
+			for _, topic := range args {
+				topic = strings.TrimPrefix(topic, "#")
+				if len(topic) > MAX_TOPIC_LENGTH {
+					return fmt.Errorf("topics can have at most %d characters", MAX_TOPIC_LENGTH)
+				}
+				if action == "block" {
+					blocked[topic] = true
+				} else {
+					delete(blocked, topic)
+				}
+			}
+			if len(blocked) > MAX_BLOCKED_TOPICS {
+				return fmt.Errorf("at most %d topics can be blocked", MAX_BLOCKED_TOPICS)
+			}
+			list.BlockedTopics = blocked
+			return nil
+		})
+	case "only":
+		if len(args) == 0 {
+			return LIST_USAGE
+		}
+		updated, err = r.state.updateList(name, func(list *BroadcastList) error {
+			list.OnlyTopics = nil
+			if len(args) == 1 && args[0] == "off" {
+				return nil
+			}
+			list.OnlyTopics = map[string]bool{}
+			for _, topic := range args {
+				list.OnlyTopics[strings.TrimPrefix(topic, "#")] = true
+			}
+			return nil
+		})
+	case "keywords":
+		if len(args) == 0 {
+			return LIST_USAGE
+		}
+		updated, err = r.state.updateList(name, func(list *BroadcastList) error {
+			if len(args) > MAX_KEYWORDS {
+				return fmt.Errorf("at most %d keywords are allowed", MAX_KEYWORDS)
+			}
+			list.Keywords = nil
+			if len(args) == 1 && args[0] == "off" {
+				return nil
+			}
+			for _, keyword := range args {
+				list.Keywords = append(list.Keywords, strings.ToLower(keyword))
+			}
+			return nil
+		})
+	default:
+		return LIST_USAGE
+	}
+	if err != nil {
+		return fmt.Sprintf("Couldn't update %s: %v.", name, err)
+	}
+	for _, id := range r.state.listMembers(name) {
+		refreshPinnedSettings(r.shards.botFor(id), r.state, id)
+	}
+	return fmt.Sprintf("Updated the filters of %s and its %d chats.", name, updated)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Adds `proposal` to the rolling cache of recent proposals or updates its cached version. The
+// cache is sorted by id and keeps the most recent MAX_CACHED_PROPOSALS proposals; updates of
+// proposals which already dropped out of it are ignored. SNS proposal ids overlap with NNS ones,
+// so only NNS proposals are cached.
+func (s *State) cache(proposal Proposal) {
+	if proposal.Source != "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i := sort.Search(len(s.Recent), func(i int) bool { return s.Recent[i].Id >= proposal.Id })
+	switch {
+	case i < len(s.Recent) && s.Recent[i].Id == proposal.Id:
+		s.Recent[i] = proposal
+	case i == len(s.Recent):
+		s.Recent = append(s.Recent, proposal)
+	default:
+		return
+	}
+	if len(s.Recent) > MAX_CACHED_PROPOSALS {
+		s.Recent = append([]Proposal(nil), s.Recent[len(s.Recent)-MAX_CACHED_PROPOSALS:]...)
+	}
+}
+
+// Returns the cached proposal `id`.
+func (s *State) cachedProposal(id uint64) (Proposal, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	i := sort.Search(len(s.Recent), func(i int) bool { return s.Recent[i].Id >= id })
+	if i < len(s.Recent) && s.Recent[i].Id == id {
+		return s.Recent[i], true
+	}
+	return Proposal{}, false
+}
+
+// Returns up to `n` of the most recent cached proposals accepted by chat `id`, newest first.
+func (s *State) recentProposals(id int64, n int) (res []Proposal) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for i := len(s.Recent) - 1; i >= 0 && len(res) < n; i-- {
+		if acceptsProposal(s.ChatIds[id], s.Recent[i]) {
+			res = append(res, s.Recent[i])
+		}
+	}
+	return
+}
+
+func lastCommand(r *Request) string {
+	n := DEFAULT_LAST_PROPOSALS
+	if len(r.args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(r.args[0]); len(r.args) > 1 || err != nil || n < 1 || n > MAX_LAST_PROPOSALS {
+			return fmt.Sprintf("Please specify a number between 1 and %d", MAX_LAST_PROPOSALS)
+		}
+	}
+	proposals := r.state.recentProposals(r.id, n)
+	if len(proposals) == 0 {
+		return "There are no recent proposals matching your filters."
+	}
+	lines := []string{"The most recent proposals matching your filters:"}
+	for _, p := range proposals {
+		lines = append(lines, fmt.Sprintf("%s %d: %s (%s)\n%s", statusBadge(p), p.Id, shortTitle(p.Title), hashtags(p), proposalURL(p.Id)))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// Returns the cached proposal `id` or fetches it from the governance API.
+func (s *State) findProposal(id uint64) (Proposal, error) {
+	if proposal, ok := s.cachedProposal(id); ok {
+		return proposal, nil
+	}
+	details, err := fetchProposal(id)
+	if err != nil {
+		log.Println("Couldn't fetch proposal", id, ":", err)
+		return Proposal{}, err
+	}
+	return details.toProposal(), nil
+}
+
+func proposalCommand(r *Request) string {
+	var proposalId uint64
+	var err error
+	if len(r.args) == 1 {
+		proposalId, err = strconv.ParseUint(strings.TrimPrefix(r.args[0], "#"), 10, 64)
+	}
+	if len(r.args) != 1 || err != nil {
+		return "Please specify a proposal id"
+	}
+	proposal, err := r.state.findProposal(proposalId)
+	if err != nil {
+		return fmt.Sprintf("Couldn't find proposal %d.", proposalId)
+	}
+	chat, _ := r.state.chat(r.id)
+	msg := tgbotapi.NewMessage(r.id, renderProposal(proposal, chat))
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	send(r.shards, r.state, msg)
+	return ""
+}
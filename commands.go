@@ -0,0 +1,545 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Invocation of a command.
+type Request struct {
+	bot     *tgbotapi.BotAPI
+	shards  *Shards
+	state   *State
+	queue   *Queue
+	message *tgbotapi.Message
+	id      int64
+	args    []string
+	isGroup bool
+}
+
+// Command of the bot. The handler returns the reply; an empty reply sends nothing.
+type Command struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	Help    string
+	Handler func(r *Request) string
+}
+
+// All commands in the order they are listed in the help message. Initialized in init(), as
+// the help message itself is generated from this list.
+var commands []*Command
+
+func init() {
+	commands = []*Command{
+		{Name: "/start", Help: "subscribe to the notifications", Handler: startCommand},
+		{Name: "/stop", Help: "unsubscribe", Handler: stopCommand},
+		{Name: "/restore", Usage: "[chat id]", Help: "subscribe again with the settings from before /stop", Handler: restoreCommand},
+		{Name: "/pause", Help: "pause the notifications while keeping your settings", Handler: pauseCommand},
+		{Name: "/resume", Usage: "[skip]", Help: "resume the notifications, optionally skipping what you missed", Handler: resumeCommand},
+		{Name: "/block", Aliases: []string{"/b"}, Usage: "<topic>", Help: "block proposals with a topic, e.g. /block #ExchangeRate", Handler: blockCommand},
+		{Name: "/topics", Help: "list the topics of all proposals seen so far", Handler: topicsCommand},
+		{Name: "/topic_alerts", Usage: "on|off", Help: "get notified when a new proposal topic appears", Handler: topicAlertsCommand},
+		{Name: "/unblock", Aliases: []string{"/u"}, Usage: "<topic>", Help: "unblock proposals with a topic", Handler: unblockCommand},
+		{Name: "/blacklist", Help: "display the list of blocked topics", Handler: blacklistCommand},
+		{Name: "/preset", Usage: "<name>", Help: "only follow a bundle of topics, e.g. /preset node-operator", Handler: presetCommand},
+		{Name: "/filter_keyword", Usage: "<keyword>|off", Help: "only receive proposals mentioning one of your keywords, e.g. /filter_keyword ckBTC", Handler: filterKeywordCommand},
+		{Name: "/block_keyword", Usage: "<keyword>", Help: "drop proposals mentioning a keyword", Handler: blockKeywordCommand},
+		{Name: "/unblock_keyword", Usage: "<keyword>", Help: "stop dropping proposals mentioning a keyword", Handler: unblockKeywordCommand},
+		{Name: "/highlight", Usage: "[keyword]", Help: "show a keyword in bold in the summaries, e.g. /highlight boundary node", Handler: highlightCommand},
+		{Name: "/unhighlight", Usage: "<keyword>|all", Help: "stop highlighting a keyword", Handler: unhighlightCommand},
+		{Name: "/tags", Help: "list the sub-tags of governance proposals, which can be blocked like topics", Handler: tagsCommand},
+		{Name: "/only", Usage: "<topics>|off", Help: "only receive proposals with the given topics, e.g. /only SubnetManagement NetworkEconomics", Handler: onlyCommand},
+		{Name: "/governance_only", Aliases: []string{"/gov"}, Help: "only receive governance proposals", Handler: governanceOnlyCommand},
+		{Name: "/last", Usage: "[n]", Help: "list the most recent proposals matching your filters", Handler: lastCommand},
+		{Name: "/proposal", Usage: "<proposal id>", Help: "show a proposal", Handler: proposalCommand},
+		{Name: "/search", Usage: "<words>", Help: "search the recent proposals", Handler: searchCommand},
+		{Name: "/important_only", Usage: "on|off", Help: "only receive proposals with a high importance score", Handler: importantOnlyCommand},
+		{Name: "/deadlines", Aliases: []string{"/pending"}, Help: "list the open proposals sorted by voting deadline", Handler: deadlinesCommand},
+		{Name: "/watch", Usage: "<proposal id>", Help: "add an open proposal to the watchlist of the group", Handler: watchCommand},
+		{Name: "/unwatch", Usage: "<proposal id>", Help: "remove a proposal from the watchlist", Handler: unwatchCommand},
+		{Name: "/watchlist", Help: "show the status and deadline of the watched proposals", Handler: watchlistCommand},
+		{Name: "/catchup", Usage: "<proposal id>", Help: "receive the proposals since the given id again", Handler: catchupCommand},
+		{Name: "/live_tally", Usage: "on|off", Help: "keep the notifications updated with the current tally", Handler: liveTallyCommand},
+		{Name: "/decisions", Usage: "on|off", Help: "get a follow-up when a proposal you were notified about is decided", Handler: decisionsCommand},
+		{Name: "/neuron_votes", Usage: "on|off", Help: "receive the votes of known neurons on decided governance proposals", Handler: neuronVotesCommand},
+		{Name: "/reward_reminders", Usage: "<neuron id>|off", Help: "get reminded when your neuron stops voting on governance proposals", Handler: rewardRemindersCommand},
+		{Name: "/proposer", Usage: "<neuron id>", Help: "see statistics about the proposals of a neuron", Handler: proposerCommand},
+		{Name: "/follow_proposer", Usage: "<neuron id>", Help: "receive all proposals of a neuron, regardless of your filters", Handler: followProposerCommand},
+		{Name: "/unfollow_proposer", Usage: "<neuron id>", Help: "stop following a proposer", Handler: unfollowProposerCommand},
+		{Name: "/block_proposer", Usage: "<neuron id>", Help: "drop the proposals of a neuron", Handler: blockProposerCommand},
+		{Name: "/unblock_proposer", Usage: "<neuron id>", Help: "stop dropping the proposals of a neuron", Handler: unblockProposerCommand},
+		{Name: "/subscribe_sns", Usage: "[name|root canister id]", Help: "also receive the proposals of an SNS DAO, e.g. /subscribe_sns OpenChat", Handler: subscribeSNSCommand},
+		{Name: "/unsubscribe_sns", Usage: "<name|root canister id>", Help: "stop receiving the proposals of an SNS DAO", Handler: unsubscribeSNSCommand},
+		{Name: "/leaderboard", Help: "see the most active proposers and known neurons", Handler: leaderboardCommand},
+		{Name: "/summary_length", Usage: "<length>", Help: "set the number of characters after which summaries get shortened", Handler: summaryLengthCommand},
+		{Name: "/template", Usage: "[layout|off]", Help: "customize the layout of the notifications", Handler: templateCommand},
+		{Name: "/previews", Usage: "on|off", Help: "control the previews of linked proposals", Handler: previewsCommand},
+		{Name: "/tldr", Usage: "on|off", Help: "prepend a short generated TL;DR to the summaries", Handler: tldrCommand},
+		{Name: "/footer", Usage: "[text|off|default]", Help: "set the footer of the notifications", Handler: footerCommand},
+		{Name: "/format", Usage: "compact|full", Help: "switch between one-line and full notifications", Handler: formatCommand},
+		{Name: "/digest", Usage: "daily|off", Help: "receive one digest of all new proposals per day instead of a message per proposal", Handler: digestCommand},
+		{Name: "/weekly_recap", Usage: "on|off", Help: "receive a summary of the proposals of the past week every Monday", Handler: weeklyRecapCommand},
+		{Name: "/window", Usage: "<from> <to> [days]|off", Help: "only receive notifications in a window, e.g. /window 08:00 20:00 weekdays", Handler: windowCommand},
+		{Name: "/quiet", Usage: "<from> <to>|off", Help: "hold notifications during quiet hours, e.g. /quiet 23:00 07:00", Handler: quietCommand},
+		{Name: "/pin_settings", Usage: "on|off", Help: "pin a message showing the current settings (groups only)", Handler: pinSettingsCommand},
+		{Name: "/auto_pin", Usage: "on|off", Help: "pin critical proposals until they are decided (channels and groups)", Handler: autoPinCommand},
+		{Name: "/apitoken", Usage: "[revoke]", Help: "create a token for the REST API scoped to this chat", Handler: apiTokenCommand},
+		{Name: "/transfer", Help: "move or copy the settings to another chat", Handler: transferCommand},
+		{Name: "/redeem", Usage: "<code> [copy] [replace]", Help: "apply the settings of another chat", Handler: redeemCommand},
+		{Name: "/delivery", Usage: "<proposal id>", Help: "see how a proposal was delivered to this chat (admins only)", Handler: deliveryCommand},
+		{Name: "/downtime_notices", Usage: "on|off", Help: "get notified when the bot was down and proposals are back-filled", Handler: downtimeNoticesCommand},
+		{Name: "/keyboard", Usage: "on|off", Help: "show buttons for the most common actions (private chats only)", Handler: keyboardCommand},
+		{Name: "/help", Help: "show this message", Handler: helpCommand},
+		{Name: "/timezone", Usage: "<zone>|off", Help: "show times in your time zone, e.g. /timezone Europe/Zurich", Handler: timezoneCommand},
+		{Name: "/language", Usage: "<code>", Help: "choose the language of the bot messages", Handler: languageCommand},
+		{Name: "/status", Help: "see the health and freshness of the proposal sources", Handler: statusCommand},
+		{Name: "/attest", Usage: "<proposal id>", Help: "attest that you reproduced the build of an upgrade proposal (verifiers only)", Handler: attestCommand},
+		{Name: "/export", Usage: "<from> <to> [csv|json]", Help: "export the relayed proposals (admin chat only)", Handler: exportCommand},
+		{Name: "/list", Usage: "[<name> <action> ...]", Help: "manage the broadcast lists of chats (admin chat only)", Handler: listCommand},
+		{Name: "/stats", Help: "see the subscribers, blocked topics and delivery statistics (admin chat only)", Handler: statsCommand},
+		{Name: "/queue", Help: "see the state of the delivery queue (admin chat only)", Handler: queueCommand},
+		{Name: "/feedback", Help: "see the results of the feedback poll (admin chat only)", Handler: feedbackCommand},
+		{Name: "/jobs", Help: "see the upcoming runs of the scheduled jobs (admin chat only)", Handler: jobsCommand},
+		{Name: "/feedback_poll", Usage: "on|off", Help: "get asked monthly whether the notifications are useful", Handler: feedbackPollCommand},
+		{Name: "/telemetry", Usage: "[on|off]", Help: "control the participation in anonymized usage statistics", Handler: telemetryCommand},
+	}
+}
+
+// Returns the command with the given name or alias.
+func findCommand(name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func getHelpMessage(lang string) string {
+	lines := []string{translate(lang, "Available commands:")}
+	for _, c := range commands {
+		line := c.Name
+		if c.Usage != "" {
+			line += " " + c.Usage
+		}
+		if len(c.Aliases) > 0 {
+			line += " (or " + strings.Join(c.Aliases, ", ") + ")"
+		}
+		lines = append(lines, line+" — "+translate(lang, c.Help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parses a single on/off argument.
+func parseSwitch(args []string) (enabled bool, ok bool) {
+	if len(args) != 1 || args[0] != "on" && args[0] != "off" {
+		return false, false
+	}
+	return args[0] == "on", true
+}
+
+const NOT_SUBSCRIBED = "Please /start the bot first."
+
+func startCommand(r *Request) string {
+	chat, defaults := defaultChat(r.message.Chat.Type)
+	r.state.addChatId(r.id, chat)
+	msg := r.tr("Subscribed.")
+	if defaults != "" {
+		msg += " " + defaults
+	}
+	if r.message.Chat.IsPrivate() {
+		msg += " " + r.tr("Use /keyboard on to get buttons for the most common actions.")
+	}
+	return msg + "\n\n" + getHelpMessage(chat.Language)
+}
+
+func helpCommand(r *Request) string {
+	if !r.state.allowHelp(r.id, time.Now()) {
+		return ""
+	}
+	return getHelpMessage(language(r.state, r.id))
+}
+
+// Returns true and records the time if chat `id` didn't get a help message within the last
+// HELP_THROTTLE_INTERVAL before `now`.
+func (s *State) allowHelp(id int64, now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if last, ok := s.lastHelp[id]; ok && now.Sub(last) < HELP_THROTTLE_INTERVAL {
+		return false
+	}
+	if s.lastHelp == nil {
+		s.lastHelp = map[int64]time.Time{}
+	}
+	s.lastHelp[id] = now
+	return true
+}
+
+func stopCommand(r *Request) string {
+	// Translated before the language is removed with the chat.
+	msg := r.tr("Unsubscribed. If this was an accident, /restore brings back all your settings.")
+	r.state.removeChatId(r.id, "stopped")
+	return msg
+}
+
+func pauseCommand(r *Request) string {
+	if _, ok := r.state.setPaused(r.id, true, false); !ok {
+		return NOT_SUBSCRIBED
+	}
+	return "Notifications paused; your settings are kept. Use /resume to receive what you missed, " +
+		"or /resume skip to continue without it."
+}
+
+func resumeCommand(r *Request) string {
+	if len(r.args) > 1 || len(r.args) == 1 && r.args[0] != "skip" {
+		return "Please use /resume or /resume skip"
+	}
+	missed, ok := r.state.setPaused(r.id, false, len(r.args) == 0)
+	if !ok {
+		return NOT_SUBSCRIBED
+	}
+	if missed > 0 {
+		return fmt.Sprintf("Notifications resumed; the %d proposals you missed will follow shortly.", missed)
+	}
+	return "Notifications resumed."
+}
+
+// Returns the topic passed as the only argument; the hashtag form `#Topic` is accepted as well.
+func topicArgument(args []string) (string, bool) {
+	if len(args) != 1 {
+		return "", false
+	}
+	return strings.TrimPrefix(args[0], "#"), true
+}
+
+func blockCommand(r *Request) string {
+	topic, ok := topicArgument(r.args)
+	if !ok {
+		return "Please specify one topic"
+	}
+	r.state.blockTopic(r.id, topic)
+	return r.state.blockedTopics(r.id)
+}
+
+func unblockCommand(r *Request) string {
+	topic, ok := topicArgument(r.args)
+	if !ok {
+		return "Please specify one topic"
+	}
+	r.state.unblockTopic(r.id, topic)
+	return r.state.blockedTopics(r.id)
+}
+
+func blacklistCommand(r *Request) string {
+	return r.state.blockedTopics(r.id)
+}
+
+func governanceOnlyCommand(r *Request) string {
+	if !r.state.setOnlyTopics(r.id, []string{TOPIC_GOVERNANCE}) {
+		return NOT_SUBSCRIBED
+	}
+	return "From now on, you'll only see the governance proposals."
+}
+
+func onlyCommand(r *Request) string {
+	if len(r.args) == 0 {
+		return r.state.blockedTopics(r.id)
+	}
+	if len(r.args) == 1 && r.args[0] == "off" {
+		if !r.state.setOnlyTopics(r.id, nil) {
+			return NOT_SUBSCRIBED
+		}
+		return "You'll receive all proposals except the ones with blocked topics again."
+	}
+	if len(r.args) > MAX_BLOCKED_TOPICS {
+		return fmt.Sprintf("Please specify at most %d topics", MAX_BLOCKED_TOPICS)
+	}
+	var topics []string
+	for _, arg := range r.args {
+		topic := strings.TrimPrefix(arg, "#")
+		if len(topic) > MAX_TOPIC_LENGTH {
+			return "Please specify valid topics"
+		}
+		topics = append(topics, topic)
+	}
+	if !r.state.setOnlyTopics(r.id, topics) {
+		return NOT_SUBSCRIBED
+	}
+	return r.state.blockedTopics(r.id)
+}
+
+// Lists the open proposals retained by the tracker; falls back to the governance API until the
+// tracker learned their deadlines, e.g. right after the first start.
+func deadlinesCommand(r *Request) string {
+	proposals := r.state.openProposals()
+	if len(proposals) == 0 {
+		var err error
+		if proposals, err = fetchOpenProposals(); err != nil {
+			log.Println("Couldn't fetch open proposals:", err)
+			return "Couldn't fetch the open proposals, please try again later."
+		}
+	}
+	return r.state.deadlines(r.id, proposals)
+}
+
+func neuronVotesCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !r.state.setKnownNeuronVotes(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "You'll receive the votes of known neurons once a governance proposal is decided."
+	}
+	return "You won't receive the votes of known neurons anymore."
+}
+
+func leaderboardCommand(r *Request) string {
+	return r.state.leaderboard()
+}
+
+func summaryLengthCommand(r *Request) string {
+	var length int
+	var err error
+	if len(r.args) == 1 {
+		length, err = strconv.Atoi(r.args[0])
+	}
+	if len(r.args) != 1 || err != nil || length < MIN_SUMMARY_LENGTH || length > MAX_SUMMARY_LENGTH {
+		return fmt.Sprintf("Please specify a length between %d and %d", MIN_SUMMARY_LENGTH, MAX_SUMMARY_LENGTH)
+	}
+	if !r.state.setSummaryLength(r.id, length) {
+		return NOT_SUBSCRIBED
+	}
+	return fmt.Sprintf("Summaries longer than %d characters will be shortened.", length)
+}
+
+func windowCommand(r *Request) string {
+	if len(r.args) == 0 {
+		if chat, ok := r.state.chat(r.id); ok && chat.Window != nil {
+			return "Your delivery window: " + chat.Window.String() + "."
+		}
+		return "You have no delivery window; all proposals are delivered immediately."
+	}
+	var window *DeliveryWindow
+	if len(r.args) != 1 || r.args[0] != "off" {
+		var err error
+		if window, err = parseDeliveryWindow(r.args); err != nil {
+			return "Couldn't set the window: " + err.Error() + "."
+		}
+	}
+	if !r.state.setDeliveryWindow(r.id, window) {
+		return NOT_SUBSCRIBED
+	}
+	if window == nil {
+		return "Delivery window removed; all proposals are delivered immediately."
+	}
+	return "Proposals arriving outside of " + window.String() + " will be delivered at the window start."
+}
+
+func quietCommand(r *Request) string {
+	if len(r.args) == 0 {
+		if chat, ok := r.state.chat(r.id); ok && chat.Quiet != nil {
+			return "Your quiet hours: " + chat.Quiet.String() + "."
+		}
+		return "You have no quiet hours."
+	}
+	var quiet *QuietHours
+	if len(r.args) != 1 || r.args[0] != "off" {
+		var err error
+		if quiet, err = parseQuietHours(r.args); err != nil {
+			return "Couldn't set the quiet hours: " + err.Error() + "."
+		}
+	}
+	if !r.state.setQuietHours(r.id, quiet) {
+		return NOT_SUBSCRIBED
+	}
+	if quiet == nil {
+		return "Quiet hours removed."
+	}
+	return "Proposals arriving during " + quiet.String() + " will be delivered afterwards."
+}
+
+func pinSettingsCommand(r *Request) string {
+	if !r.isGroup {
+		return "Pinned settings are only available in groups."
+	}
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !enabled {
+		unpinSettings(r.bot, r.state, r.id)
+		return "The settings message was unpinned."
+	}
+	if err := pinSettings(r.bot, r.state, r.id); err != nil {
+		log.Println("Couldn't pin the settings message in", r.id, ":", err)
+		return "Couldn't pin the settings message; please make sure the bot is allowed to pin messages."
+	}
+	return ""
+}
+
+func transferCommand(r *Request) string {
+	if !isAdmin(r.bot, r.message) {
+		return "Only admins can transfer the settings of this chat."
+	}
+	code, err := r.state.createTransfer(r.id)
+	if err != nil {
+		return NOT_SUBSCRIBED
+	}
+	return fmt.Sprintf("Enter /redeem %s in another chat within the next hour to move the settings of this chat there, "+
+		"or /redeem %s copy to copy them.", code, code)
+}
+
+func redeemCommand(r *Request) string {
+	if len(r.args) == 0 {
+		return "Please specify the transfer code"
+	}
+	keep, replace := false, false
+	for _, arg := range r.args[1:] {
+		switch arg {
+		case "copy":
+			keep = true
+		case "replace":
+			replace = true
+		default:
+			return "Please use /redeem <code> [copy] [replace]"
+		}
+	}
+	if !isAdmin(r.bot, r.message) {
+		return "Only admins can change the settings of this chat."
+	}
+	err := r.state.redeemTransfer(r.id, r.args[0], keep, replace)
+	if err == errSubscribed {
+		return fmt.Sprintf("This chat has its own settings already. Use /redeem %s replace to replace them.", strings.Join(r.args, " "))
+	}
+	if err != nil {
+		return "Couldn't redeem the code: " + err.Error() + "."
+	}
+	return "The settings were applied to this chat."
+}
+
+func deliveryCommand(r *Request) string {
+	var proposalId uint64
+	var err error
+	if len(r.args) == 1 {
+		proposalId, err = strconv.ParseUint(r.args[0], 10, 64)
+	}
+	if len(r.args) != 1 || err != nil {
+		return "Please specify a proposal id"
+	}
+	if !isAdmin(r.bot, r.message) {
+		return "Only admins can request delivery reports."
+	}
+	return r.state.deliveryReport(r.id, proposalId)
+}
+
+func telemetryCommand(r *Request) string {
+	if len(r.args) == 0 {
+		chat, _ := r.state.chat(r.id)
+		return telemetryDescription(chat.Telemetry)
+	}
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !r.state.setTelemetry(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	return telemetryDescription(enabled)
+}
+
+func catchupCommand(r *Request) string {
+	var from uint64
+	var err error
+	if len(r.args) == 1 {
+		from, err = strconv.ParseUint(r.args[0], 10, 64)
+	}
+	if len(r.args) != 1 || err != nil {
+		return "Please specify a proposal id"
+	}
+	chat, ok := r.state.chat(r.id)
+	if !ok {
+		return NOT_SUBSCRIBED
+	}
+	last := r.state.lastSeenProposal()
+	if from > last {
+		return fmt.Sprintf("The last proposal is %d.", last)
+	}
+	to := from + MAX_CATCHUP_PROPOSALS - 1
+	if to > last {
+		to = last
+	}
+	if !r.state.startCatchUp(r.id) {
+		return "A catch-up is already in progress, please wait until it's finished."
+	}
+	go func() {
+		defer r.state.finishCatchUp(r.id)
+		catchUp(r.shards, r.state, r.id, chat, from, to)
+	}()
+	return fmt.Sprintf("Delivering the proposals %d to %d matching your filters again.", from, to)
+}
+
+// Returns false if a catch-up is already in progress in chat `id`; otherwise records it.
+func (s *State) startCatchUp(id int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.catchingUp[id] {
+		return false
+	}
+	if s.catchingUp == nil {
+		s.catchingUp = map[int64]bool{}
+	}
+	s.catchingUp[id] = true
+	return true
+}
+
+func (s *State) finishCatchUp(id int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.catchingUp, id)
+}
+
+func formatCommand(r *Request) string {
+	if len(r.args) != 1 || r.args[0] != FORMAT_COMPACT && r.args[0] != FORMAT_FULL {
+		return "Please specify compact or full"
+	}
+	format := r.args[0]
+	// The full format is the default.
+	if format == FORMAT_FULL {
+		format = ""
+	}
+	if !r.state.setFormat(r.id, format) {
+		return NOT_SUBSCRIBED
+	}
+	if format == FORMAT_COMPACT {
+		return "Proposals will be shown as a single line with the title, the topic and the link."
+	}
+	return "Proposals will be shown with their summary."
+}
+
+func downtimeNoticesCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !r.state.setDowntimeNotices(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "You'll be notified when the bot restarts after a downtime, so you can tell silence apart from no proposals."
+	}
+	return "You won't be notified about downtimes anymore."
+}
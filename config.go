@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting which operators can override with a command-line flag, an environment variable or a
+// key in the configuration file, in this order of precedence. The environment variable is the
+// upper-case flag name with underscores, e.g. `POLL_INTERVAL` for `-poll-interval`, and the key
+// is the flag name.
+type tunable struct {
+	name     string
+	validate func() error
+}
+
+var tunables []tunable
+
+func envName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func stringTunable(p *string, name, usage string) {
+	flag.StringVar(p, name, *p, usage)
+	tunables = append(tunables, tunable{name, func() error {
+		if *p == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		return nil
+	}})
+}
+
+// Registers a string setting which may be empty, e.g. a URL which enables a feature when set.
+func optionalStringTunable(p *string, name, usage string) {
+	flag.StringVar(p, name, *p, usage)
+	tunables = append(tunables, tunable{name, func() error { return nil }})
+}
+
+func boolTunable(p *bool, name, usage string) {
+	flag.BoolVar(p, name, *p, usage)
+	tunables = append(tunables, tunable{name, func() error { return nil }})
+}
+
+// Registers a setting with durations per topic, e.g. `SubnetManagement=10m,NodeAdmin=5m`.
+func topicDurationsTunable(p *topicDurations, name, usage string) {
+	flag.Var(p, name, usage)
+	tunables = append(tunables, tunable{name, func() error { return nil }})
+}
+
+func durationTunable(p *time.Duration, name, usage string, min time.Duration) {
+	flag.DurationVar(p, name, *p, usage)
+	tunables = append(tunables, tunable{name, func() error {
+		if *p < min {
+			return fmt.Errorf("must be at least %s", min)
+		}
+		return nil
+	}})
+}
+
+func intTunable(p *int, name, usage string, min, max int) {
+	flag.IntVar(p, name, *p, usage)
+	tunables = append(tunables, tunable{name, func() error {
+		if *p < min || *p > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}})
+}
+
+// Path of the configuration file; set with -config or CONFIG_PATH.
+var CONFIG_PATH = os.Getenv("CONFIG_PATH")
+
+// Secrets are only read from the environment, so they don't show up in the process list or in a
+// configuration file which may be shared.
+var secrets = []struct{ name, usage string }{
+	{"TOKENS", "comma-separated tokens of the bots; TOKEN for a single bot"},
+	{"TLDR_API_KEY", "key of the API writing the TL;DRs; disables the TL;DRs if not set"},
+	{"TELEGRAPH_TOKEN", "access token of the Telegraph account long payloads are published with"},
+	{"BACKUP_KEY", "random 32-byte key in base64 the backups are encrypted with"},
+	{"BACKUP_TOKEN", "bearer token the storage canister accepts backups with"},
+}
+
+// Reads a configuration file in a subset of TOML: one `key = value` pair per line, where the value
+// is a quoted string or a bare number, and comments starting with `#`. Tables aren't supported.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key, value := strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+		if strings.HasPrefix(value, `"`) {
+			s, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			rest := strings.TrimSpace(value[len(s):])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %s after the value", i+1, rest)
+			}
+			value, _ = strconv.Unquote(s)
+		} else if comment := strings.Index(value, "#"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %s", i+1, key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// Lists the flags with their environment variables and defaults for -help.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [import <file> | export <from> <to> [csv|json] | restore-backup]\n\n"+
+		"Flags, with their environment variable in brackets; the keys of the configuration file are the flag names:\n", os.Args[0])
+	flag.VisitAll(func(f *flag.Flag) {
+		env := envName(f.Name)
+		if f.Name == "config" {
+			env = "CONFIG_PATH"
+		}
+		fmt.Fprintf(out, "  -%s (%s)\n    \t%s (default %q)\n", f.Name, env, f.Usage, f.DefValue)
+	})
+	fmt.Fprintln(out, "\nSecrets, only read from the environment:")
+	for _, s := range secrets {
+		fmt.Fprintf(out, "  %s\n    \t%s\n", s.name, s.usage)
+	}
+}
+
+// Sets tunable `name` from its environment variable or else from the configuration file, unless
+// it was given as a flag.
+func applyTunable(name string, explicit map[string]bool, file map[string]string) {
+	if explicit[name] {
+		return
+	}
+	if value, ok := os.LookupEnv(envName(name)); ok {
+		if err := flag.Set(name, value); err != nil {
+			log.Fatalln("Couldn't parse", envName(name), ":", err)
+		}
+	} else if value, ok := file[name]; ok {
+		if err := flag.Set(name, value); err != nil {
+			log.Fatalln("Couldn't parse", name, "in the configuration file:", err)
+		}
+	}
+}
+
+// Applies the configuration file, the environment variables and the command-line flags to the
+// tunables and exits if any of them is invalid.
+func configure() {
+	flag.Usage = usage
+	flag.StringVar(&CONFIG_PATH, "config", CONFIG_PATH, "path of the configuration file")
+	stringTunable(&URL, "source-url", "URL of the proposal feed")
+	stringTunable(&GOVERNANCE_API_URL, "governance-api-url", "URL of the governance API")
+	stringTunable(&PROPOSAL_URL_TEMPLATE, "proposal-url-template", "link to a proposal, with {id} for its id")
+	stringTunable(&STATE_PATH, "state-path", "path of the file the state is persisted to")
+	stringTunable(&HISTORY_PATH, "history-path", "path of the history of the relayed proposals")
+	stringTunable(&TLDR_API_URL, "tldr-api-url", "OpenAI-compatible API writing the TL;DRs")
+	stringTunable(&TLDR_MODEL, "tldr-model", "model writing the TL;DRs")
+	durationTunable(&NNS_POLL_INTERVALL, "poll-interval", "interval between two polls for new proposals", 10*time.Second)
+	durationTunable(&STATUS_POLL_INTERVAL, "status-poll-interval", "interval between two polls of the status of open proposals", time.Minute)
+	durationTunable(&TALLY_REFRESH_INTERVAL, "tally-refresh-interval", "interval between two updates of the live tallies", time.Minute)
+	durationTunable(&STATE_PERSISTENCE_INTERVAL, "persistence-interval", "interval between two writes of the state", time.Second)
+	durationTunable(&SELF_TEST_INTERVAL, "self-test-interval", "interval between two self-tests", time.Minute)
+	durationTunable(&TELEMETRY_INTERVAL, "telemetry-interval", "interval between two telemetry reports", time.Hour)
+	durationTunable(&BACKUP_INTERVAL, "backup-interval", "interval between two backups to the storage canister", time.Hour)
+	durationTunable(&KNOWN_NEURONS_REFRESH_INTERVAL, "known-neurons-interval", "interval between two refreshes of the known neurons", time.Hour)
+	durationTunable(&DEDUP_WINDOW, "dedup-window", "time for which a chat doesn't get the same proposal twice", 0)
+	durationTunable(&DOWNTIME_NOTICE_THRESHOLD, "downtime-threshold", "downtime after which a restart is announced", time.Minute)
+	durationTunable(&UNREACHABLE_GRACE, "unreachable-grace", "time after which chats which blocked the bot are removed", time.Hour)
+	durationTunable(&TOMBSTONE_RETENTION, "tombstone-retention", "time for which the settings of removed chats can be restored", 0)
+	intTunable(&DIGEST_HOUR, "digest-hour", "hour (UTC) at which the daily digests are sent", 0, 23)
+	intTunable(&MAX_BLOCKED_TOPICS, "max-blocked-topics", "maximal number of topics a chat can block", 1, 1000)
+	intTunable(&MAX_KEYWORDS, "max-keywords", "maximal number of keywords a chat can filter by", 1, 1000)
+	intTunable(&MAX_WATCHED_PROPOSALS, "max-watched-proposals", "maximal number of proposals a chat can watch", 1, 1000)
+	intTunable(&MAX_CACHED_PROPOSALS, "archive-size", "number of recent proposals kept for /last, /proposal and /search", 1, 100000)
+	intTunable(&DEFAULT_SUMMARY_LENGTH, "summary-length", "summary length of chats which didn't choose one", MIN_SUMMARY_LENGTH, MAX_MESSAGE_LENGTH)
+	intTunable(&MAX_SUMMARY_LENGTH, "max-summary-length", "maximal summary length a chat can choose", MIN_SUMMARY_LENGTH, MAX_MESSAGE_LENGTH)
+	intTunable(&MAX_QUEUE_LENGTH, "queue-length", "maximal number of queued messages", 1, 1000000)
+	intTunable(&QUEUE_PAUSE_THRESHOLD, "queue-pause", "number of queued messages at which the discovery pauses", 1, 1000000)
+	intTunable(&DELIVERY_WORKERS, "delivery-workers", "number of concurrent senders of queued messages", 1, 100)
+	intTunable(&MAX_DELIVERY_RECORDS, "delivery-records", "number of deliveries kept per chat for /delivery", 1, 10000)
+	intTunable(&MAX_CACHED_TLDRS, "tldr-cache-size", "number of generated TL;DRs kept in memory", 1, 100000)
+	boolTunable(&LOW_MEMORY, "low-memory", "lower the defaults of the limits for hosts with 128 to 256 MB of memory")
+	boolTunable(&TESTNET, "testnet", "run against a test deployment with a separate state")
+	boolTunable(&MIRROR_MODE, "mirror-mode", "only broadcast to the mirror channels and ignore commands outside the admin chat")
+	boolTunable(&VERIFY_ARTIFACTS, "verify-artifacts", "download the artifacts of proposals and check their hashes")
+	topicDurationsTunable(&TOPIC_DELAYS, "topic-delays", "delays of the notifications per topic, e.g. SubnetManagement=10m")
+	topicDurationsTunable(&TOPIC_COOLDOWNS, "topic-cooldowns", "cooldowns collapsing bursts per topic, e.g. SubnetManagement=1h")
+	optionalStringTunable(&HTTP_ADDR, "http-addr", "address of the statistics page, the metrics and the REST API, e.g. :8080")
+	optionalStringTunable(&TELEMETRY_URL, "telemetry-url", "URL the anonymized usage statistics are posted to")
+	optionalStringTunable(&HEARTBEAT_URL, "heartbeat-url", "URL of an uptime monitor pinged after every poll")
+	optionalStringTunable(&FOOTER, "footer", "footer of the notifications of chats without their own")
+	optionalStringTunable(&SCORING_RULES_PATH, "scoring-rules-path", "path of the JSON file with the scoring rules")
+	optionalStringTunable(&BACKUP_CANISTER_URL, "backup-canister-url", "URL of the storage canister the state is backed up to")
+
+	flag.Parse()
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	file := map[string]string{}
+	if CONFIG_PATH != "" {
+		var err error
+		if file, err = readConfigFile(CONFIG_PATH); err != nil {
+			log.Fatalln("Couldn't read the configuration file", CONFIG_PATH, ":", err)
+		}
+	}
+	known := map[string]bool{}
+	for _, t := range tunables {
+		known[t.name] = true
+	}
+	for key := range file {
+		if !known[key] {
+			log.Fatalln("Unknown setting", key, "in the configuration file", CONFIG_PATH)
+		}
+	}
+	applyTunable("low-memory", explicit, file)
+	applyLowMemoryDefaults(explicit)
+	for _, t := range tunables {
+		applyTunable(t.name, explicit, file)
+	}
+	for _, t := range tunables {
+		if err := t.validate(); err != nil {
+			log.Fatalln("Invalid value for", t.name, ":", err)
+		}
+	}
+	if QUEUE_PAUSE_THRESHOLD > MAX_QUEUE_LENGTH {
+		log.Fatalln("Invalid value for queue-pause : must be at most queue-length")
+	}
+	if DEFAULT_SUMMARY_LENGTH > MAX_SUMMARY_LENGTH {
+		log.Fatalln("Invalid value for summary-length : must be at most max-summary-length")
+	}
+	if err := validateTemplate(FOOTER, MAX_FOOTER_LENGTH); err != nil {
+		log.Fatalln("Invalid value for footer :", err)
+	}
+	BACKUP_CANISTER_URL = strings.TrimSuffix(BACKUP_CANISTER_URL, "/")
+	if _, err := backupKey(); BACKUP_CANISTER_URL != "" && err != nil {
+		log.Fatalln("Invalid BACKUP_KEY:", err)
+	}
+	configureTestnet()
+}
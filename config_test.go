@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"comments and blank lines", "# settings\n\n  # indented\n", map[string]string{}, false},
+		{"string and number", "source-url = \"https://example.com\"\narchive-size = 500\n",
+			map[string]string{"source-url": "https://example.com", "archive-size": "500"}, false},
+		{"trailing comments", "poll-interval = \"2m\" # faster\ndigest-hour = 8 # UTC\n",
+			map[string]string{"poll-interval": "2m", "digest-hour": "8"}, false},
+		{"escapes", `footer = "Vote \"yes\" # or no\n"`, map[string]string{"footer": "Vote \"yes\" # or no\n"}, false},
+		{"no spaces", "queue-length=100", map[string]string{"queue-length": "100"}, false},
+		{"missing value", "archive-size\n", nil, true},
+		{"missing key", "= 5\n", nil, true},
+		{"unterminated string", "footer = \"text\n", nil, true},
+		{"text after the string", "footer = \"a\" b\n", nil, true},
+		{"duplicate key", "archive-size = 1\narchive-size = 2\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readConfigFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readConfigFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readConfigFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMissingConfigFile(t *testing.T) {
+	if _, err := readConfigFile(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("readConfigFile() of a missing file returned no error")
+	}
+}
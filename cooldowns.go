@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Cooldowns per topic: after a notification, further proposals of the topic arriving within the
+// cooldown are collapsed into one combined message per chat.
+var TOPIC_COOLDOWNS = topicDurations{}
+
+// Burst of proposals of a topic collected for a chat until the cooldown is over.
+type Burst struct {
+	Until     time.Time  `json:"until"`
+	Proposals []Proposal `json:"proposals,omitempty"`
+}
+
+// Returns false for critical proposals, which are always notified on their own so they can be
+// pinned and voted on with the buttons.
+func collapsible(proposal Proposal) bool {
+	return proposal.Source != "" || !CRITICAL_TOPICS[proposal.Topic]
+}
+
+// Returns true if `proposal` was collected into a running burst of chat `id`; otherwise a new
+// burst is started and the proposal should be sent right away.
+func (s *State) collapse(id int64, proposal Proposal, cooldown time.Duration) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	now := time.Now()
+	if burst := chat.Bursts[proposal.Topic]; burst != nil && now.Before(burst.Until) {
+		burst.Proposals = append(burst.Proposals, proposal)
+		return true
+	}
+	if chat.Bursts == nil {
+		chat.Bursts = map[string]*Burst{}
+	}
+	chat.Bursts[proposal.Topic] = &Burst{Until: now.Add(cooldown)}
+	return false
+}
+
+// Removes all bursts whose cooldown is over and returns their proposals by chat and topic.
+func (s *State) takeBursts(now time.Time) map[int64]map[string][]Proposal {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := map[int64]map[string][]Proposal{}
+	for id, chat := range s.ChatIds {
+		for topic, burst := range chat.Bursts {
+			if now.Before(burst.Until) {
+				continue
+			}
+			delete(chat.Bursts, topic)
+			if len(burst.Proposals) == 0 {
+				continue
+			}
+			if res[id] == nil {
+				res[id] = map[string][]Proposal{}
+			}
+			res[id][topic] = burst.Proposals
+		}
+	}
+	return res
+}
+
+// Sends the proposals collected during the cooldowns which are over as one message per chat and
+// topic; scheduled every minute. They are deferred for chats which can't receive them right now.
+func flushBursts(shards *Shards, state *State) {
+	now := time.Now()
+	for id, bursts := range state.takeBursts(now) {
+		chat, ok := state.chat(id)
+		if !ok {
+			continue
+		}
+		var topics []string
+		for topic := range bursts {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		for _, topic := range topics {
+			flushBurst(shards, state, id, chat, topic, bursts[topic], now)
+		}
+	}
+}
+
+func flushBurst(shards *Shards, state *State, id int64, chat Chat, topic string, proposals []Proposal, now time.Time) {
+	if !chat.deliverable(now) {
+		for _, p := range proposals {
+			state.deferProposal(id, p)
+			state.recordDeferral(id, p)
+		}
+		return
+	}
+	// A burst of several proposals has no message of its own for each of them.
+	single := len(proposals) == 1
+	text := renderBurst(topic, proposals)
+	if single {
+		text = renderProposal(proposals[0], chat)
+	}
+	msg := tgbotapi.NewMessage(id, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	if markup, ok := notificationKeyboard(state, proposals[0], chat); ok && single {
+		msg.ReplyMarkup = markup
+	}
+	sent, err := send(shards, state, msg)
+	messageId := 0
+	if single {
+		messageId = sent.MessageID
+	}
+	for _, p := range proposals {
+		state.recordDelivery(id, p.Source, p.Id, messageId, err)
+		if err == nil {
+			metrics.observeDelivery(p.Source, p.Id)
+		}
+	}
+	log.Println("Sent", len(proposals), "collapsed", topic, "proposals to", id)
+}
+
+// Renders the proposals of `topic` collected during a cooldown.
+func renderBurst(topic string, proposals []Proposal) string {
+	lines := []string{fmt.Sprintf("📦 <b>%d more #%s proposals</b>", len(proposals), topic)}
+	for _, p := range proposals {
+		lines = append(lines, fmt.Sprintf("%s %d: %s%s\n%s", statusBadge(p), p.Id, htmlTitle(p.Title), artifactMark(p), htmlURL(p)))
+	}
+	return strings.Join(lines, "\n\n")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTakeBursts(t *testing.T) {
+	now := time.Now()
+	p := func(id uint64, topic string) Proposal { return Proposal{Id: id, Topic: topic} }
+	state := State{ChatIds: map[int64]*Chat{
+		1: {Bursts: map[string]*Burst{
+			"SubnetManagement": {Until: now.Add(-time.Minute), Proposals: []Proposal{p(1, "SubnetManagement"), p(2, "SubnetManagement")}},
+			"NodeAdmin":        {Until: now.Add(-time.Minute), Proposals: []Proposal{p(3, "NodeAdmin")}},
+			"ExchangeRate":     {Until: now.Add(time.Minute), Proposals: []Proposal{p(4, "ExchangeRate")}},
+		}},
+		2: {Bursts: map[string]*Burst{"NodeAdmin": {Until: now.Add(-time.Minute)}}},
+	}}
+	want := map[int64]map[string][]Proposal{1: {
+		"SubnetManagement": {p(1, "SubnetManagement"), p(2, "SubnetManagement")},
+		"NodeAdmin":        {p(3, "NodeAdmin")},
+	}}
+	if got := state.takeBursts(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("takeBursts() = %v, want %v", got, want)
+	}
+	if _, ok := state.ChatIds[1].Bursts["ExchangeRate"]; !ok || len(state.ChatIds[1].Bursts) != 1 {
+		t.Errorf("takeBursts() left %v, want only the running burst", state.ChatIds[1].Bursts)
+	}
+}
+
+func TestCollapsible(t *testing.T) {
+	tests := []struct {
+		proposal Proposal
+		want     bool
+	}{
+		{Proposal{Topic: "SubnetManagement"}, true},
+		{Proposal{Topic: "IcOsVersionElection"}, false},
+		{Proposal{Topic: "IcOsVersionElection", Source: "sns-1"}, true},
+	}
+	for _, tt := range tests {
+		if got := collapsible(tt.proposal); got != tt.want {
+			t.Errorf("collapsible(%+v) = %v, want %v", tt.proposal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (s *State) setDecisionNotices(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.DecisionNotices = enabled
+	return true
+}
+
+// Renders the follow-up about a decided proposal, e.g. "Proposal #12345 was ADOPTED (97% yes)".
+func renderDecision(proposal Proposal) string {
+	name := "Proposal"
+	if proposal.Source != "" {
+		name = proposal.SourceName + " proposal"
+	}
+	text := fmt.Sprintf("%s %s #%d was %s", statusBadge(proposal), name, proposal.Id, proposal.Status)
+	if proposal.Tally != nil && proposal.Tally.Total > 0 {
+		text += fmt.Sprintf(" (%.0f%% yes)", 100*proposal.Tally.Yes/proposal.Tally.Total)
+	}
+	return text + "\n" + shortTitle(proposal.Title)
+}
+
+// Replies to the notifications about the decided `proposal` in the chats which opted in. Chats
+// receiving the known neuron votes on governance proposals already get a follow-up.
+func notifyDecision(shards *Shards, state *State, proposal Proposal) {
+	count := 0
+	for id, messageId := range state.notifiedChats(proposal) {
+		chat, ok := state.chat(id)
+		if !ok || !chat.DecisionNotices || chat.KnownNeuronVotes && proposal.Topic == TOPIC_GOVERNANCE {
+			continue
+		}
+		msg := tgbotapi.NewMessage(id, renderDecision(proposal))
+		msg.ReplyToMessageID = messageId
+		msg.AllowSendingWithoutReply = true
+		if _, err := send(shards, state, msg); err == nil {
+			state.recordStatusUpdate(id, proposal)
+			count++
+		}
+	}
+	if count > 0 {
+		log.Println("Sent the decision on proposal", proposal.Id, "to", count, "users")
+	}
+}
+
+func decisionsCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /decisions on or /decisions off"
+	}
+	if !r.state.setDecisionNotices(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "You'll get a follow-up message when a proposal you were notified about is decided."
+	}
+	return "Follow-ups about decided proposals disabled."
+}
@@ -0,0 +1,35 @@
+package main
+
+import "strings"
+
+// Defaults applied at /start by the chat type reported by Telegram, with the commands which
+// change them. Private chats receive every proposal immediately.
+var CHAT_DEFAULTS = map[string]struct {
+	apply    func(chat *Chat)
+	overview []string
+}{
+	"group":      {groupDefaults, groupDefaultsOverview},
+	"supergroup": {groupDefaults, groupDefaultsOverview},
+	"channel": {func(chat *Chat) { chat.Format = FORMAT_COMPACT },
+		[]string{"one-line notifications (/format full to change)"}},
+}
+
+// Groups see all traffic of their members, so they only get a daily digest of governance proposals.
+func groupDefaults(chat *Chat) {
+	chat.FilterMode, chat.OnlyTopics = FILTER_ONLY, map[string]bool{TOPIC_GOVERNANCE: true}
+	chat.DeliveryMode = DELIVERY_DAILY
+}
+
+var groupDefaultsOverview = []string{"only governance proposals (/only off to change)", "a daily digest (/digest off to change)"}
+
+// Returns the configuration of a new chat of type `chatType` and a description of the defaults
+// which differ from the ones of private chats.
+func defaultChat(chatType string) (*Chat, string) {
+	chat := &Chat{BlockedTopics: map[string]bool{}}
+	defaults, ok := CHAT_DEFAULTS[chatType]
+	if !ok {
+		return chat, ""
+	}
+	defaults.apply(chat)
+	return chat, "This " + chatType + " gets " + strings.Join(defaults.overview, " and ") + "."
+}
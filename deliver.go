@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Classes of errors returned by the Bot API, each handled differently.
+type errorClass int
+
+const (
+	ERROR_OTHER errorClass = iota
+	// The user blocked the bot, deactivated the account or the bot was removed from the chat.
+	ERROR_BLOCKED
+	// The bot is muted or restricted in a group or channel.
+	ERROR_NO_PERMISSION
+	// The group was upgraded to a supergroup with a new chat id.
+	ERROR_MIGRATED
+	// The bot exceeded the flood limits and needs to wait before sending again.
+	ERROR_RATE_LIMITED
+	// The formatted text couldn't be parsed.
+	ERROR_PARSE
+)
+
+var errorClassNames = map[errorClass]string{
+	ERROR_OTHER:         "other",
+	ERROR_BLOCKED:       "blocked",
+	ERROR_NO_PERMISSION: "no permission",
+	ERROR_MIGRATED:      "migrated",
+	ERROR_RATE_LIMITED:  "rate limited",
+	ERROR_PARSE:         "parse error",
+}
+
+func (c errorClass) String() string {
+	return errorClassNames[c]
+}
+
+// Substrings of the error descriptions returned when the bot is muted or restricted.
+var missingPermissionErrors = []string{
+	"not enough rights",
+	"have no rights",
+	"CHAT_WRITE_FORBIDDEN",
+	"CHAT_RESTRICTED",
+}
+
+// Classifies `err` based on the error code and response parameters of the Bot API.
+func classifyError(err error) (errorClass, *tgbotapi.Error) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return ERROR_OTHER, nil
+	}
+	for _, s := range missingPermissionErrors {
+		if strings.Contains(apiErr.Message, s) {
+			return ERROR_NO_PERMISSION, apiErr
+		}
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests && apiErr.RetryAfter > 0:
+		return ERROR_RATE_LIMITED, apiErr
+	case apiErr.Code == http.StatusBadRequest && apiErr.MigrateToChatID != 0:
+		return ERROR_MIGRATED, apiErr
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "can't parse entities"):
+		return ERROR_PARSE, apiErr
+	case apiErr.Code == http.StatusForbidden:
+		return ERROR_BLOCKED, apiErr
+	}
+	return ERROR_OTHER, apiErr
+}
+
+// Sends the message, split into a numbered sequence of messages if it exceeds the length
+// limit of Telegram. Returns the first sent message.
+func send(shards *Shards, state *State, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	msg.Text = withTestnetBanner(msg.Text)
+	parts := splitMessage(msg.Text, MAX_MESSAGE_LENGTH-MAX_PART_PREFIX_LENGTH, msg.ParseMode == tgbotapi.ModeHTML)
+	if len(parts) == 1 {
+		return deliver(shards, state, msg)
+	}
+	var first tgbotapi.Message
+	for i, part := range parts {
+		msg.Text = fmt.Sprintf("(%d/%d) %s", i+1, len(parts), part)
+		sent, err := deliver(shards, state, msg)
+		if err != nil {
+			return first, err
+		}
+		if i == 0 {
+			first = sent
+		}
+		// Follow a migration of the chat which happened while sending the previous part.
+		if sent.Chat != nil {
+			msg.ChatID = sent.Chat.ID
+		}
+	}
+	return first, nil
+}
+
+// Characters reserved in every part of a split HTML message for closing and reopening the tags
+// open at the cut.
+const MAX_TAG_OVERHEAD = 200
+
+// Splits `text` into parts of at most `limit` characters, preferably at paragraph boundaries,
+// otherwise at line breaks or spaces. For `html` text, cuts never fall inside a tag or an entity,
+// and the tags open at a cut, like a multi-line <pre> block, are closed at the end of the part and
+// reopened at the start of the next one, so every part stays valid HTML.
+func splitMessage(text string, limit int, html bool) []string {
+	var parts []string
+	overhead := 0
+	if html {
+		overhead = MAX_TAG_OVERHEAD
+	}
+	for {
+		if _, truncated := truncate(text, limit); !truncated {
+			return append(parts, text)
+		}
+		head, _ := truncate(text, limit-overhead)
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if cut = lastCut(head, sep, html); cut > 0 {
+				break
+			}
+		}
+		if cut <= 0 {
+			cut = len(head)
+			if html {
+				cut = safeCut(head)
+			}
+		}
+		part, rest := strings.TrimSpace(text[:cut]), strings.TrimSpace(text[cut:])
+		if html {
+			open := openTags(part)
+			for i := len(open) - 1; i >= 0; i-- {
+				part += "</" + tagName(open[i]) + ">"
+			}
+			rest = strings.Join(open, "") + rest
+		}
+		parts = append(parts, part)
+		text = rest
+	}
+}
+
+// Returns the position of the last `sep` in `head` which isn't inside an HTML tag, or -1.
+func lastCut(head, sep string, html bool) int {
+	for end := len(head); end > 0; {
+		i := strings.LastIndex(head[:end], sep)
+		if i <= 0 || !html || !insideTag(head, i) {
+			return i
+		}
+		end = i
+	}
+	return -1
+}
+
+func insideTag(text string, i int) bool {
+	return strings.LastIndex(text[:i], "<") > strings.LastIndex(text[:i], ">")
+}
+
+// Returns the end of `head` moved back before any tag or entity it cuts through.
+func safeCut(head string) int {
+	cut := len(head)
+	if open := strings.LastIndex(head, "<"); open > strings.LastIndex(head, ">") {
+		cut = open
+	}
+	if amp := strings.LastIndex(head[:cut], "&"); amp > strings.LastIndex(head[:cut], ";") {
+		cut = amp
+	}
+	if cut == 0 {
+		return len(head)
+	}
+	return cut
+}
+
+// Returns the opening tags which aren't closed at the end of `text`, outermost first.
+func openTags(text string) (open []string) {
+	for {
+		start := strings.Index(text, "<")
+		if start < 0 {
+			return
+		}
+		end := strings.Index(text[start:], ">")
+		if end < 0 {
+			return
+		}
+		tag := text[start : start+end+1]
+		text = text[start+end+1:]
+		if !strings.HasPrefix(tag, "</") {
+			open = append(open, tag)
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(tag, "</"), ">")
+		for i := len(open) - 1; i >= 0; i-- {
+			if tagName(open[i]) == name {
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+// Returns the name of the opening tag `tag`, e.g. a for <a href="...">.
+func tagName(tag string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(tag, "<"), ">")
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Sends a single message and handles delivery errors: marks the chat as unreachable if the
+// user blocked the bot, pauses deliveries if the bot lost the permission to post in the chat,
+// follows group migrations, waits out rate limits and falls back to plain text if the HTML
+// couldn't be parsed.
+func deliver(shards *Shards, state *State, msg tgbotapi.MessageConfig) (sent tgbotapi.Message, err error) {
+	defer func() { metrics.observeSend(err) }()
+	for attempt := 1; ; attempt++ {
+		sent, err = shards.botFor(msg.ChatID).Send(msg)
+		if err == nil {
+			return
+		}
+		log.Println("Couldn't send message to", msg.ChatID, ":", err)
+		class, apiErr := classifyError(err)
+		if attempt < MAX_SEND_ATTEMPTS {
+			metrics.observeRetry(class)
+		}
+		switch class {
+		case ERROR_BLOCKED:
+			state.markUnreachable(msg.ChatID)
+		case ERROR_NO_PERMISSION:
+			state.setMuted(msg.ChatID, true)
+		case ERROR_MIGRATED:
+			if attempt < MAX_SEND_ATTEMPTS {
+				state.migrateChat(msg.ChatID, apiErr.MigrateToChatID)
+				msg.ChatID = apiErr.MigrateToChatID
+				continue
+			}
+		case ERROR_RATE_LIMITED:
+			if attempt < MAX_SEND_ATTEMPTS {
+				time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+				continue
+			}
+		case ERROR_PARSE:
+			if msg.ParseMode != "" {
+				log.Println("Retrying the message to", msg.ChatID, "as plain text")
+				msg.ParseMode = ""
+				msg.Text = htmlToPlainText(msg.Text)
+				continue
+			}
+		}
+		return
+	}
+}
+
+// Edits the text of a message like deliver sends it: falls back to plain text if the HTML couldn't
+// be parsed.
+func editMessage(shards *Shards, edit tgbotapi.EditMessageTextConfig) error {
+	edit.Text = withTestnetBanner(edit.Text)
+	_, err := shards.botFor(edit.ChatID).Request(edit)
+	if class, _ := classifyError(err); err != nil && class == ERROR_PARSE && edit.ParseMode != "" {
+		log.Println("Couldn't parse the edited message in", edit.ChatID, ", retrying as plain text:", err)
+		edit.ParseMode = ""
+		edit.Text = htmlToPlainText(edit.Text)
+		_, err = shards.botFor(edit.ChatID).Request(edit)
+	}
+	return err
+}
+
+// Periodically sends a test message to all chats where the bot lost the permission to post
+// and resumes the deliveries once the message goes through.
+func probeMutedChats(shards *Shards, state *State) {
+	ticker := time.NewTicker(PERMISSION_PROBE_INTERVAL)
+	for range ticker.C {
+		for _, id := range state.mutedChatIds() {
+			msg := tgbotapi.NewMessage(id, "The bot can post again; notifications are resumed.")
+			if _, err := shards.botFor(id).Send(msg); err != nil {
+				if class, _ := classifyError(err); class != ERROR_NO_PERMISSION {
+					log.Println("Couldn't probe chat", id, ":", err)
+				}
+				continue
+			}
+			state.setMuted(id, false)
+		}
+	}
+}
+
+// Periodically retries to reach the chats which blocked the bot with a chat action, which is
+// invisible to the user. Deferred notifications are delivered once the chat is reachable again.
+func retryUnreachableChats(shards *Shards, state *State) {
+	ticker := time.NewTicker(UNREACHABLE_RETRY_INTERVAL)
+	for range ticker.C {
+		for _, id := range state.unreachableChatIds() {
+			_, err := shards.botFor(id).Request(tgbotapi.NewChatAction(id, tgbotapi.ChatTyping))
+			if err == nil {
+				state.markReachable(id)
+			} else if class, _ := classifyError(err); class == ERROR_BLOCKED {
+				state.markUnreachable(id)
+			} else {
+				log.Println("Couldn't retry chat", id, ":", err)
+			}
+		}
+	}
+}
+
+// Redelivers the proposals with ids from `from` to `to` matching the filters of `chat`.
+func catchUp(shards *Shards, state *State, id int64, chat Chat, from, to uint64) {
+	delivered := 0
+	for proposalId := from; proposalId <= to; proposalId++ {
+		details, err := fetchProposal(proposalId)
+		if err != nil {
+			log.Println("Couldn't fetch proposal", proposalId, "for the catch-up of", id, ":", err)
+			continue
+		}
+		proposal := details.toProposal()
+		if !acceptsProposal(&chat, proposal) {
+			continue
+		}
+		msg := tgbotapi.NewMessage(id, renderProposal(proposal, chat))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		if _, err := send(shards, state, msg); err != nil {
+			return
+		}
+		delivered++
+	}
+	log.Println("Redelivered", delivered, "proposals to", id)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestClassifyError(t *testing.T) {
+	apiErr := func(code int, message string, params tgbotapi.ResponseParameters) error {
+		return &tgbotapi.Error{Code: code, Message: message, ResponseParameters: params}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want errorClass
+	}{
+		{"network error", errors.New("connection reset"), ERROR_OTHER},
+		{"blocked", apiErr(http.StatusForbidden, "Forbidden: bot was blocked by the user", tgbotapi.ResponseParameters{}), ERROR_BLOCKED},
+		{"muted", apiErr(http.StatusBadRequest, "Bad Request: not enough rights to send text messages", tgbotapi.ResponseParameters{}), ERROR_NO_PERMISSION},
+		{"restricted channel", apiErr(http.StatusForbidden, "Forbidden: CHAT_WRITE_FORBIDDEN", tgbotapi.ResponseParameters{}), ERROR_NO_PERMISSION},
+		{"migrated", apiErr(http.StatusBadRequest, "Bad Request: group chat was upgraded", tgbotapi.ResponseParameters{MigrateToChatID: -100123}), ERROR_MIGRATED},
+		{"rate limited", apiErr(http.StatusTooManyRequests, "Too Many Requests: retry after 5", tgbotapi.ResponseParameters{RetryAfter: 5}), ERROR_RATE_LIMITED},
+		{"rate limited without delay", apiErr(http.StatusTooManyRequests, "Too Many Requests", tgbotapi.ResponseParameters{}), ERROR_OTHER},
+		{"parse error", apiErr(http.StatusBadRequest, "Bad Request: can't parse entities: unclosed tag", tgbotapi.ResponseParameters{}), ERROR_PARSE},
+		{"other bad request", apiErr(http.StatusBadRequest, "Bad Request: message text is empty", tgbotapi.ResponseParameters{}), ERROR_OTHER},
+		{"wrapped", fmt.Errorf("sending: %w", apiErr(http.StatusForbidden, "Forbidden: user is deactivated", tgbotapi.ResponseParameters{})), ERROR_BLOCKED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Checks that every part of a split HTML message fits the limit, cuts no tag or entity and
+// closes all the tags it opens.
+func checkHTMLParts(t *testing.T, parts []string, limit int) {
+	t.Helper()
+	for i, part := range parts {
+		if n := len([]rune(part)); n > limit {
+			t.Errorf("part %d has %d characters, more than %d", i, n, limit)
+		}
+		if strings.Count(part, "<") != strings.Count(part, ">") {
+			t.Errorf("part %d cuts through a tag: %q", i, part)
+		}
+		if open := openTags(part); len(open) > 0 {
+			t.Errorf("part %d leaves %v open", i, open)
+		}
+		if strings.Count(part, "&") != strings.Count(part, ";") {
+			t.Errorf("part %d cuts through an entity: %q", i, part)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	var code []string
+	for i := 0; i < 100; i++ {
+		code = append(code, fmt.Sprintf("line %d", i))
+	}
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		html  bool
+		// Expected parts; only the properties of the parts are checked if nil.
+		want []string
+	}{
+		{"short", "Hello", 10, false, []string{"Hello"}},
+		{"paragraphs", "aaaa\n\nbbbb", 6, false, []string{"aaaa", "bbbb"}},
+		{"lines before spaces", "aa bb\ncc dd", 8, false, []string{"aa bb", "cc dd"}},
+		{"spaces", "aaa bbb ccc", 8, false, []string{"aaa bbb", "ccc"}},
+		{"no separator", "abcdefghij", 4, false, []string{"abcd", "efgh", "ij"}},
+		{"multi-line pre block", "<b>Code</b>\n<pre>" + strings.Join(code, "\n") + "</pre>", 400, true, nil},
+		{"nested tags", strings.Repeat("<b>bold <i>italic and bold</i> text</b> ", 40), 300, true, nil},
+		{"link", strings.Repeat(`see <a href="https://example.com/some/long/path">the forum post</a> `, 20), 300, true, nil},
+		{"entities without spaces", "<code>" + strings.Repeat("x&amp;y&lt;", 100) + "</code>", 300, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.text, tt.limit, tt.html)
+			if tt.want != nil {
+				if !reflect.DeepEqual(parts, tt.want) {
+					t.Errorf("splitMessage() = %q, want %q", parts, tt.want)
+				}
+				return
+			}
+			if len(parts) < 2 {
+				t.Fatalf("splitMessage() returned %d part, expected the text to be split", len(parts))
+			}
+			checkHTMLParts(t, parts, tt.limit)
+		})
+	}
+}
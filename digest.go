@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Delivery modes of a chat.
+const (
+	DELIVERY_IMMEDIATE = ""
+	DELIVERY_DAILY     = "daily"
+)
+
+// Maximal number of proposals listed in a digest.
+const MAX_DIGEST_PROPOSALS = 50
+
+// Proposals buffered for the digest of a chat and the number of older ones which didn't fit.
+type Digest struct {
+	Proposals []Proposal
+	Overflow  int
+}
+
+// Sets the delivery mode of chat `id`. Switching back to immediate deliveries discards the
+// proposals buffered for the digest.
+func (s *State) setDeliveryMode(id int64, mode string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.DeliveryMode = mode
+	if mode == DELIVERY_IMMEDIATE {
+		chat.DigestBuffer, chat.DigestOverflow = nil, 0
+	}
+	return true
+}
+
+// Buffers `proposal` for the next digest of chat `id`. Only the most recent MAX_DIGEST_PROPOSALS
+// proposals are kept, without their summaries; the dropped ones are counted.
+func (s *State) bufferForDigest(id int64, proposal Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return
+	}
+	proposal.Summary = ""
+	chat.DigestBuffer = append(chat.DigestBuffer, proposal)
+	if len(chat.DigestBuffer) > MAX_DIGEST_PROPOSALS {
+		chat.DigestOverflow += len(chat.DigestBuffer) - MAX_DIGEST_PROPOSALS
+		chat.DigestBuffer = chat.DigestBuffer[len(chat.DigestBuffer)-MAX_DIGEST_PROPOSALS:]
+	}
+}
+
+// Removes and returns the digests of all chats the bot can post to. The digest of a paused or
+// muted chat is kept for the next day.
+func (s *State) takeDigests() map[int64]Digest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := map[int64]Digest{}
+	for id, chat := range s.ChatIds {
+		if len(chat.DigestBuffer) > 0 && !chat.Paused && chat.MutedSince == nil && chat.UnreachableSince == nil {
+			res[id] = Digest{chat.DigestBuffer, chat.DigestOverflow}
+			chat.DigestBuffer, chat.DigestOverflow = nil, 0
+		}
+	}
+	return res
+}
+
+// Returns the next time the digests are sent after `t`.
+func nextDigest(t time.Time) time.Time {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day(), DIGEST_HOUR, 0, 0, 0, time.UTC)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Sends the accumulated proposals to every chat in the daily delivery mode; scheduled daily at
+// DIGEST_HOUR.
+func deliverDigests(shards *Shards, state *State) {
+	digests := state.takeDigests()
+	for id, digest := range digests {
+		chat, _ := state.chat(id)
+		msg := tgbotapi.NewMessage(id, renderDigest(digest, chat))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		_, err := send(shards, state, msg)
+		for _, p := range digest.Proposals {
+			state.recordDelivery(id, p.Source, p.Id, 0, err)
+			if err == nil {
+				metrics.observeDelivery(p.Source, p.Id)
+			}
+		}
+	}
+	log.Println("Sent the daily digest to", len(digests), "users")
+}
+
+// Renders the proposals of a day grouped by topic, headed by the date in the time zone of `chat`.
+func renderDigest(digest Digest, chat Chat) string {
+	byTopic := map[string][]Proposal{}
+	for _, p := range digest.Proposals {
+		byTopic[p.Topic] = append(byTopic[p.Topic], p)
+	}
+	var topics []string
+	for topic := range byTopic {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	date := time.Now().In(chat.location()).Format("Mon, 2 Jan")
+	lines := []string{fmt.Sprintf("📰 <b>Daily digest of %s: %d new proposals</b>", date, len(digest.Proposals)+digest.Overflow)}
+	for _, topic := range topics {
+		lines = append(lines, fmt.Sprintf("\n<b>#%s</b>", topic))
+		for _, p := range byTopic[topic] {
+			lines = append(lines, fmt.Sprintf("%s <a href=\"%s\">%s</a>%s", statusBadge(p), htmlURL(p), htmlTitle(p.Title), artifactMark(p)))
+		}
+	}
+	if digest.Overflow > 0 {
+		lines = append(lines, fmt.Sprintf("\n… and %d older proposals, see /last.", digest.Overflow))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func digestCommand(r *Request) string {
+	if len(r.args) != 1 || r.args[0] != DELIVERY_DAILY && r.args[0] != "off" {
+		return "Please use /digest daily or /digest off"
+	}
+	mode := DELIVERY_IMMEDIATE
+	if r.args[0] == DELIVERY_DAILY {
+		mode = DELIVERY_DAILY
+	}
+	if !r.state.setDeliveryMode(r.id, mode) {
+		return NOT_SUBSCRIBED
+	}
+	if mode == DELIVERY_DAILY {
+		chat, _ := r.state.chat(r.id)
+		return fmt.Sprintf("From now on, you'll receive one digest of all new proposals per day at %s.", digestTime(chat))
+	}
+	return "Digest disabled; proposals are delivered immediately again."
+}
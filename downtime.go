@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Returns the time since the state was last persisted, or 0 on the first run.
+func (s *State) downtime() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.Heartbeat.IsZero() {
+		return 0
+	}
+	return time.Since(s.Heartbeat)
+}
+
+// Enables or disables the notice about downtimes of the bot for chat `id`.
+func (s *State) setDowntimeNotices(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.DowntimeNotices = enabled
+	return true
+}
+
+// Returns the ids of the chats which opted into downtime notices.
+func (s *State) downtimeNoticeChatIds() (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if chat.DowntimeNotices {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+func (s *State) hasDowntimeNotices(id int64) bool {
+	chat, ok := s.chat(id)
+	return ok && chat.DowntimeNotices
+}
+
+// Informs the admin chat and the opted-in chats about how long the bot was down and how many
+// proposals will be back-filled, if the gap since the last heartbeat is longer than expected.
+// As the heartbeat is only written on persistence, gaps up to one persistence interval are normal.
+func announceRestart(shards *Shards, state *State, downtime time.Duration) {
+	if downtime < DOWNTIME_NOTICE_THRESHOLD+STATE_PERSISTENCE_INTERVAL {
+		return
+	}
+	missed := 0
+	proposals, err := fetchProposals()
+	if err != nil {
+		log.Println("Couldn't fetch the proposals missed during the downtime:", err)
+	}
+	last := state.lastSeenProposal()
+	for _, p := range proposals {
+		if p.Id > last {
+			missed++
+		}
+	}
+	text := fmt.Sprintf("The bot was down for %s; ", formatCountdown(downtime))
+	switch {
+	case err != nil:
+		text += "the proposals published in the meantime will follow shortly."
+	case missed == 0:
+		text += "no proposals were published in the meantime."
+	default:
+		text += fmt.Sprintf("the %d proposals published in the meantime will follow shortly.", missed)
+	}
+	log.Println("Announcing the downtime of", downtime, "with", missed, "missed proposals")
+	ids := state.downtimeNoticeChatIds()
+	if ADMIN_CHAT_ID != 0 && !state.hasDowntimeNotices(ADMIN_CHAT_ID) {
+		ids = append(ids, ADMIN_CHAT_ID)
+	}
+	for _, id := range ids {
+		send(shards, state, tgbotapi.NewMessage(id, text))
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Returns the chats which received a notification of their own about `proposal`, mapped to the id
+// of the sent message. Chats which only got the proposal listed in a digest, catch-up or burst are
+// skipped, as editing that message would replace the whole list.
+func (s *State) recipients(proposal Proposal) map[int64]int {
+	res := s.notifiedChats(proposal)
+	for id, messageId := range res {
+		if messageId == 0 {
+			delete(res, id)
+		}
+	}
+	return res
+}
+
+// Returns all chats which were notified about `proposal`, mapped to the id of the notification or
+// 0 if the proposal was only listed in a message about several proposals.
+func (s *State) notifiedChats(proposal Proposal) map[int64]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	res := map[int64]int{}
+	for id, chat := range s.ChatIds {
+		for _, d := range chat.Deliveries {
+			if d.of(proposal.Source, proposal.Id) && d.Sent != nil {
+				res[id] = d.MessageId
+			}
+		}
+	}
+	return res
+}
+
+// Records that an edit of `proposalId` was announced in chat `id`.
+func (s *State) recordEdit(id int64, proposalId uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.delivery(proposalId).Edits++
+	}
+}
+
+// Returns the lines removed from `old` prefixed with "-" followed by the lines added in `new`
+// prefixed with "+". Blank lines are ignored.
+func diffLines(old, new string) []string {
+	count := func(text string) map[string]int {
+		res := map[string]int{}
+		for _, line := range strings.Split(text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				res[line]++
+			}
+		}
+		return res
+	}
+	oldLines, newLines := count(old), count(new)
+	var removed, added []string
+	for _, line := range strings.Split(old, "\n") {
+		if line = strings.TrimSpace(line); line != "" && oldLines[line] > newLines[line] {
+			removed = append(removed, "- "+line)
+			oldLines[line]--
+		}
+	}
+	oldLines = count(old)
+	for _, line := range strings.Split(new, "\n") {
+		if line = strings.TrimSpace(line); line != "" && newLines[line] > oldLines[line] {
+			added = append(added, "+ "+line)
+			newLines[line]--
+		}
+	}
+	return append(removed, added...)
+}
+
+// Renders the changes between the announced version of a proposal and its edited version.
+func renderEdit(old, edited Proposal) string {
+	lines := []string{fmt.Sprintf("✏️ <b>Proposal %d was edited</b>", edited.Id)}
+	if old.Title != edited.Title {
+		lines = append(lines, fmt.Sprintf("Title: <s>%s</s> → %s",
+			html.EscapeString(shortTitle(old.Title)), html.EscapeString(shortTitle(edited.Title))))
+	} else {
+		lines = append(lines, html.EscapeString(shortTitle(edited.Title)))
+	}
+	if diff := diffLines(sanitizeSummary(old.Summary), sanitizeSummary(edited.Summary)); len(diff) > 0 {
+		if len(diff) > MAX_DIFF_LINES {
+			diff = append(diff[:MAX_DIFF_LINES], fmt.Sprintf("… %d more changed lines", len(diff)-MAX_DIFF_LINES))
+		}
+		lines = append(lines, "\nSummary changes:", "<pre>"+html.EscapeString(strings.Join(diff, "\n"))+"</pre>")
+	}
+	lines = append(lines, "\n"+proposalURL(edited.Id))
+	return strings.Join(lines, "\n")
+}
+
+// Sends the changes of an edited proposal to all chats which received the original, as a reply
+// to the original notification if it has one of its own.
+func notifyEdit(shards *Shards, state *State, old, edited Proposal) {
+	text := renderEdit(old, edited)
+	recipients := state.notifiedChats(edited)
+	for id, messageId := range recipients {
+		msg := tgbotapi.NewMessage(id, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		msg.ReplyToMessageID = messageId
+		msg.AllowSendingWithoutReply = true
+		if _, err := send(shards, state, msg); err == nil {
+			state.recordEdit(id, edited.Id)
+		}
+	}
+	log.Println("Sent the edit of proposal", edited.Id, "to", len(recipients), "users")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"unchanged", "a\nb", "a\nb", nil},
+		{"whitespace only", "a\n\n  b", "a\nb  \n", nil},
+		{"added", "a", "a\nb", []string{"+ b"}},
+		{"removed", "a\nb", "b", []string{"- a"}},
+		{"changed", "a\nb\nc", "a\nB\nc", []string{"- b", "+ B"}},
+		{"reordered", "a\nb", "b\na", nil},
+		{"duplicate removed", "a\na\nb", "a\nb", []string{"- a"}},
+		{"duplicate added", "a", "a\na", []string{"+ a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	proposal := Proposal{Id: 7}
+	state := State{ChatIds: map[int64]*Chat{1: {}, 2: {}, 3: {}, 4: {}}}
+	state.recordDelivery(1, "", 7, 100, nil)
+	// Listed in a digest only.
+	state.recordDelivery(2, "", 7, 0, nil)
+	// Notified on its own, then listed in a catch-up.
+	state.recordDelivery(3, "", 7, 300, nil)
+	state.recordDelivery(3, "", 7, 0, nil)
+	state.recordDelivery(4, "", 7, 400, errors.New("blocked"))
+	tests := []struct {
+		name string
+		got  map[int64]int
+		want map[int64]int
+	}{
+		{"recipients", state.recipients(proposal), map[int64]int{1: 100, 3: 300}},
+		{"notified chats", state.notifiedChats(proposal), map[int64]int{1: 100, 2: 0, 3: 300}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+type EventKind int
+
+const (
+	// A new proposal is ready to be announced.
+	PROPOSAL_DISCOVERED EventKind = iota
+	// The tracker observed a new status of a proposal.
+	STATUS_CHANGED
+)
+
+// Event about a proposal. For STATUS_CHANGED, `Previous` is the status before the change.
+type Event struct {
+	Kind     EventKind
+	Proposal Proposal
+	Previous string
+}
+
+// In-process event bus. Handlers run synchronously in the order of their subscription, so
+// publishing returns once all consumers processed the event.
+type Bus struct {
+	handlers map[EventKind][]func(Event)
+	lock     sync.RWMutex
+}
+
+var events = Bus{handlers: map[EventKind][]func(Event){}}
+
+func (b *Bus) subscribe(kind EventKind, handler func(Event)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.handlers[kind] = append(b.handlers[kind], handler)
+}
+
+func (b *Bus) publish(e Event) {
+	b.lock.RLock()
+	handlers := b.handlers[e.Kind]
+	b.lock.RUnlock()
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
+
+// Returns true if a recipient of NNS proposals needs the details missing in the feed: the tally
+// for the importance score, the live tally or the {tally} placeholder, and the payload for the
+// full format, which the mirror and archive channels get as well.
+func (s *State) needsDetails() bool {
+	if len(MIRROR_CHANNEL_IDS) > 0 || ARCHIVE_CHANNEL_ID != 0 {
+		return true
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, chat := range s.ChatIds {
+		if chat.ImportantOnly || chat.LiveTally || strings.Contains(chat.Template, "{tally}") ||
+			chat.Format != FORMAT_COMPACT && chat.Template == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Completes an NNS `proposal` with the tally, the payload and the creation time missing in the
+// feed if a recipient needs them, and publishes it as discovered.
+func publishDiscovered(state *State, proposal Proposal) {
+	if proposal.Tally == nil && proposal.Source == "" && state.needsDetails() {
+		if details, err := fetchProposal(proposal.Id); err == nil {
+			proposal.Action, proposal.Tally = details.Action, &details.Tally
+			proposal.Created, proposal.Payload = details.Created, formatPayload(details)
+		}
+	}
+	publishLongPayload(&proposal)
+	events.publish(Event{Kind: PROPOSAL_DISCOVERED, Proposal: proposal})
+}
+
+// Subscribes the consumers of the proposal events: the Telegram delivery, the status tracker,
+// the cache, the statistics, the history, the decision follow-ups and the metrics. SNS proposal
+// ids overlap with NNS ones, so the consumers keyed by the id only handle NNS proposals.
+func subscribeConsumers(shards *Shards, state *State, queue *Queue) {
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) { fanOut(shards, state, queue, e.Proposal) })
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) { state.track(e.Proposal) })
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) { state.cache(e.Proposal) })
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) {
+		if e.Proposal.Source == "" {
+			state.recordActivity(e.Proposal)
+		}
+	})
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) { recordDiscovery(e.Proposal) })
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) { metrics.observeDiscovered(e.Proposal.Topic) })
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) {
+		// Topics of SNS proposals are qualified with the name of the DAO, so only NNS topics are announced.
+		if state.recordTopic(e.Proposal.Topic) && e.Proposal.Source == "" {
+			notifyNewTopic(shards, state, e.Proposal.Topic)
+		}
+	})
+	events.subscribe(PROPOSAL_DISCOVERED, func(e Event) {
+		if e.Proposal.Source == "" {
+			metrics.observeDiscovery(e.Proposal)
+		}
+	})
+	events.subscribe(STATUS_CHANGED, func(e Event) {
+		if e.Proposal.Status != STATUS_OPEN {
+			if e.Proposal.Source == "" {
+				state.recordOutcome(e.Proposal.Id, e.Proposal.Status)
+			}
+			recordDecision(e.Proposal)
+			notifyDecision(shards, state, e.Proposal)
+			refreshLiveTally(shards, state, e.Proposal)
+		}
+	})
+	events.subscribe(STATUS_CHANGED, func(e Event) {
+		log.Println("Proposal", e.Proposal.Id, "changed from", e.Previous, "to", e.Proposal.Status)
+		metrics.observeStatusChange(e.Proposal.Status)
+	})
+}
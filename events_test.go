@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestNeedsDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		chat Chat
+		want bool
+	}{
+		{"full format", Chat{}, true},
+		{"compact format", Chat{Format: FORMAT_COMPACT}, false},
+		{"template without tally", Chat{Format: FORMAT_COMPACT, Template: "{title} {link}"}, false},
+		{"template with tally", Chat{Template: "{title} {tally}"}, true},
+		{"important only", Chat{Format: FORMAT_COMPACT, ImportantOnly: true}, true},
+		{"live tally", Chat{Format: FORMAT_COMPACT, LiveTally: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.chat
+			state := State{ChatIds: map[int64]*Chat{1: &chat}}
+			if got := state.needsDetails(); got != tt.want {
+				t.Errorf("needsDetails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
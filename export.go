@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Append-only log of the relayed proposals and their outcomes, kept beyond the statistics window
+// for the export.
+var HISTORY_PATH = "history.jsonl"
+
+var historyLock sync.Mutex
+
+// Entry of the history log: the discovery of a proposal or, if `Status` is set, its decision.
+// Exported records merge both entries of a proposal.
+type HistoryRecord struct {
+	Id uint64 `json:"id"`
+	// Namespace of the governance system of the proposal; empty for the NNS.
+	Source     string     `json:"source,omitempty"`
+	Topic      string     `json:"topic,omitempty"`
+	Proposer   uint64     `json:"proposer,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	Created    *time.Time `json:"created,omitempty"`
+	Discovered *time.Time `json:"discovered,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	Decided    *time.Time `json:"decided,omitempty"`
+}
+
+func appendHistory(record HistoryRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Println("Couldn't serialize the history record of proposal", record.Id, ":", err)
+		return
+	}
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	f, err := os.OpenFile(HISTORY_PATH, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println("Couldn't open the history", HISTORY_PATH, ":", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Println("Couldn't append to the history", HISTORY_PATH, ":", err)
+	}
+}
+
+func recordDiscovery(proposal Proposal) {
+	now := time.Now().UTC()
+	record := HistoryRecord{Id: proposal.Id, Source: proposal.Source, Topic: proposal.Topic, Proposer: proposal.Proposer, Title: proposal.Title, Discovered: &now}
+	if proposal.Created > 0 {
+		created := time.Unix(proposal.Created, 0).UTC()
+		record.Created = &created
+	}
+	appendHistory(record)
+}
+
+func recordDecision(proposal Proposal) {
+	now := time.Now().UTC()
+	appendHistory(HistoryRecord{Id: proposal.Id, Source: proposal.Source, Status: proposal.Status, Decided: &now})
+}
+
+// Returns the proposals discovered in [from, to) with their latest outcome, sorted by id.
+func readHistory(from, to time.Time) ([]HistoryRecord, error) {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	f, err := os.Open(HISTORY_PATH)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records := map[string]*HistoryRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Println("Skipping a corrupt line of the history:", err)
+			continue
+		}
+		key := proposalKey(Proposal{Id: entry.Id, Source: entry.Source})
+		record := records[key]
+		switch {
+		case entry.Status != "" && record != nil:
+			record.Status, record.Decided = entry.Status, entry.Decided
+		case entry.Status == "" && record == nil:
+			records[key] = &entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var res []HistoryRecord
+	for _, r := range records {
+		if !r.Discovered.Before(from) && r.Discovered.Before(to) {
+			res = append(res, *r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Source != res[j].Source {
+			return res[i].Source < res[j].Source
+		}
+		return res[i].Id < res[j].Id
+	})
+	return res, nil
+}
+
+// Writes `records` to `w` as CSV or JSON.
+func writeExport(w io.Writer, records []HistoryRecord, format string) error {
+	if format == "json" {
+		if records == nil {
+			records = []HistoryRecord{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	timestamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "topic", "proposer", "title", "created", "discovered", "status", "decided", "source"})
+	for _, r := range records {
+		out.Write([]string{strconv.FormatUint(r.Id, 10), r.Topic, strconv.FormatUint(r.Proposer, 10), r.Title,
+			timestamp(r.Created), timestamp(r.Discovered), r.Status, timestamp(r.Decided), r.Source})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Parses the arguments `<from> <to> [csv|json]` of an export, with dates like 2024-01-31. The
+// range includes both days.
+func parseExportArgs(args []string) (from, to time.Time, format string, err error) {
+	if len(args) < 2 || len(args) > 3 {
+		return from, to, "", fmt.Errorf("expected <from> <to> [csv|json]")
+	}
+	if from, err = time.Parse("2006-01-02", args[0]); err != nil {
+		return
+	}
+	if to, err = time.Parse("2006-01-02", args[1]); err != nil {
+		return
+	}
+	format = "csv"
+	if len(args) == 3 {
+		format = args[2]
+	}
+	if format != "csv" && format != "json" {
+		return from, to, "", fmt.Errorf("unknown format %s", format)
+	}
+	return from, to.AddDate(0, 0, 1), format, nil
+}
+
+// Writes the export for the command-line arguments to stdout.
+func runExport(args []string) {
+	from, to, format, err := parseExportArgs(args)
+	if err != nil {
+		log.Fatalln("Usage: nns-proposals-bot export <from> <to> [csv|json]:", err)
+	}
+	records, err := readHistory(from, to)
+	if err != nil {
+		log.Fatalln("Couldn't read the history", HISTORY_PATH, ":", err)
+	}
+	if err := writeExport(os.Stdout, records, format); err != nil {
+		log.Fatalln("Couldn't write the export:", err)
+	}
+}
+
+func exportCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	from, to, format, err := parseExportArgs(r.args)
+	if err != nil {
+		return "Please use /export <from> <to> [csv|json], e.g. /export 2024-01-01 2024-03-31"
+	}
+	records, err := readHistory(from, to)
+	if err != nil {
+		log.Println("Couldn't read the history", HISTORY_PATH, ":", err)
+		return "Couldn't read the history."
+	}
+	var buf bytes.Buffer
+	if err := writeExport(&buf, records, format); err != nil {
+		log.Println("Couldn't write the export:", err)
+		return "Couldn't write the export."
+	}
+	name := fmt.Sprintf("proposals-%s-%s.%s", r.args[0], r.args[1], format)
+	doc := tgbotapi.NewDocument(r.id, tgbotapi.FileBytes{Name: name, Bytes: buf.Bytes()})
+	doc.Caption = fmt.Sprintf("%d proposals relayed between %s and %s", len(records), r.args[0], r.args[1])
+	if _, err := r.bot.Send(doc); err != nil {
+		log.Println("Couldn't send the export to", r.id, ":", err)
+		return "Couldn't send the export."
+	}
+	return ""
+}
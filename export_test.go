@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		from, to time.Time
+		format   string
+		wantErr  bool
+	}{
+		{"csv by default", []string{"2024-01-01", "2024-01-31"}, day("2024-01-01"), day("2024-02-01"), "csv", false},
+		{"json", []string{"2024-01-01", "2024-01-01", "json"}, day("2024-01-01"), day("2024-01-02"), "json", false},
+		{"explicit csv", []string{"2023-12-31", "2024-01-01", "csv"}, day("2023-12-31"), day("2024-01-02"), "csv", false},
+		{"missing end", []string{"2024-01-01"}, time.Time{}, time.Time{}, "", true},
+		{"too many arguments", []string{"2024-01-01", "2024-01-31", "csv", "x"}, time.Time{}, time.Time{}, "", true},
+		{"invalid start", []string{"01/01/2024", "2024-01-31"}, time.Time{}, time.Time{}, "", true},
+		{"invalid end", []string{"2024-01-01", "tomorrow"}, time.Time{}, time.Time{}, "", true},
+		{"unknown format", []string{"2024-01-01", "2024-01-31", "xml"}, time.Time{}, time.Time{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, format, err := parseExportArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportArgs(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !from.Equal(tt.from) || !to.Equal(tt.to) || format != tt.format {
+				t.Errorf("parseExportArgs(%q) = %s, %s, %s, want %s, %s, %s", tt.args, from, to, format, tt.from, tt.to, tt.format)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CALLBACK_FEEDBACK   = "feedback:"
+	FEEDBACK_USEFUL     = "useful"
+	FEEDBACK_NOT_USEFUL = "not_useful"
+	FEEDBACK_DIGEST     = "digest"
+	// Topic offered to be blocked with one tap, as it's the most frequent one.
+	FEEDBACK_BLOCK_TOPIC = "ExchangeRate"
+)
+
+func (s *State) setFeedbackPoll(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.FeedbackPoll = enabled
+	return true
+}
+
+func (s *State) feedbackPollChatIds() (res []int64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for id, chat := range s.ChatIds {
+		if chat.FeedbackPoll {
+			res = append(res, id)
+		}
+	}
+	return
+}
+
+// Records the latest answer of chat `id` to the feedback poll.
+func (s *State) recordFeedback(id int64, answer string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Feedback[id] = answer
+}
+
+// Returns the number of chats finding the notifications useful and not useful.
+func (s *State) feedbackResults() (useful, notUseful int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, answer := range s.Feedback {
+		if answer == FEEDBACK_USEFUL {
+			useful++
+		} else {
+			notUseful++
+		}
+	}
+	return
+}
+
+// Returns the first day of the month after `t` at DIGEST_HOUR.
+func nextMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, DIGEST_HOUR, 0, 0, 0, time.UTC)
+}
+
+func feedbackKeyboard() tgbotapi.InlineKeyboardMarkup {
+	block, _ := blockTopicButton(Proposal{Topic: FEEDBACK_BLOCK_TOPIC})
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👍 Useful", CALLBACK_FEEDBACK+FEEDBACK_USEFUL),
+			tgbotapi.NewInlineKeyboardButtonData("👎 Not useful", CALLBACK_FEEDBACK+FEEDBACK_NOT_USEFUL),
+		),
+		tgbotapi.NewInlineKeyboardRow(block, tgbotapi.NewInlineKeyboardButtonData("📰 Daily digest", CALLBACK_FEEDBACK+FEEDBACK_DIGEST)),
+	)
+}
+
+// Asks the chats which opted in whether the notifications are useful; scheduled monthly.
+func askForFeedback(shards *Shards, state *State) {
+	ids := state.feedbackPollChatIds()
+	for _, id := range ids {
+		msg := tgbotapi.NewMessage(id, "Are these notifications useful? If there are too many, block a topic or switch to a daily digest.")
+		msg.ReplyMarkup = feedbackKeyboard()
+		send(shards, state, msg)
+	}
+	log.Println("Asked", len(ids), "users for feedback")
+}
+
+// Handles the buttons of the feedback poll and returns the answer to the callback query. The
+// block button is handled like the one on notifications.
+func handleFeedback(bot *tgbotapi.BotAPI, state *State, query *tgbotapi.CallbackQuery) string {
+	id := query.Message.Chat.ID
+	if !isChatAdmin(bot, query.Message.Chat, query.From) {
+		return "Only admins can answer."
+	}
+	switch answer := strings.TrimPrefix(query.Data, CALLBACK_FEEDBACK); answer {
+	case FEEDBACK_USEFUL, FEEDBACK_NOT_USEFUL:
+		state.recordFeedback(id, answer)
+		return "Thanks for your feedback!"
+	case FEEDBACK_DIGEST:
+		if !state.setDeliveryMode(id, DELIVERY_DAILY) {
+			return NOT_SUBSCRIBED
+		}
+		refreshPinnedSettings(bot, state, id)
+		chat, _ := state.chat(id)
+		return fmt.Sprintf("You'll receive a daily digest at %s from now on; /digest off to undo.", digestTime(chat))
+	}
+	return ""
+}
+
+func feedbackPollCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /feedback_poll on or /feedback_poll off"
+	}
+	if !r.state.setFeedbackPoll(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "Once a month, you'll be asked whether the notifications are useful."
+	}
+	return "Feedback poll disabled."
+}
+
+func feedbackCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	useful, notUseful := r.state.feedbackResults()
+	if useful+notUseful == 0 {
+		return "No chat answered the feedback poll yet."
+	}
+	return fmt.Sprintf("Feedback poll: %d chats find the notifications useful, %d don't (%.0f%% useful).",
+		useful, notUseful, 100*float64(useful)/float64(useful+notUseful))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MAX_FOOTER_LENGTH = 300
+
+// Footer appended to all notifications unless a chat overrides it, e.g. a link to a voting guide.
+var FOOTER = ""
+
+// Returns the footer template of the notifications in this chat or an empty string if it has none.
+func (c Chat) footer() string {
+	switch {
+	case c.HideFooter:
+		return ""
+	case c.Footer != "":
+		return c.Footer
+	}
+	return FOOTER
+}
+
+// Returns the summary length of the chat minus the length of the footer template, but at least
+// MIN_SUMMARY_LENGTH.
+func (c Chat) summaryBudget() int {
+	budget := c.summaryLength() - len([]rune(c.footer()))
+	if budget < MIN_SUMMARY_LENGTH {
+		return MIN_SUMMARY_LENGTH
+	}
+	return budget
+}
+
+func (s *State) setFooter(id int64, footer string, hidden bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Footer, chat.HideFooter = footer, hidden
+	return true
+}
+
+// Sets the footer to the text after the command, which may span several lines.
+func footerCommand(r *Request) string {
+	if len(r.args) == 0 {
+		chat, _ := r.state.chat(r.id)
+		current := "The notifications in this chat have no footer."
+		if footer := chat.footer(); footer != "" {
+			current = "The footer of the notifications in this chat is:\n\n" + footer
+		}
+		return fmt.Sprintf("%s\n\nUse /footer followed by a text to set your own footer; it supports the placeholders of /template. "+
+			"Use /footer off to hide the footer and /footer default to use the default footer of the bot.", current)
+	}
+	footer, hidden := "", false
+	switch {
+	case len(r.args) == 1 && r.args[0] == "off":
+		hidden = true
+	case len(r.args) == 1 && r.args[0] == "default":
+	default:
+		text := strings.TrimSpace(r.message.Text)
+		footer = strings.TrimSpace(text[strings.IndexAny(text, " \n\t"):])
+	}
+	if err := validateTemplate(footer, MAX_FOOTER_LENGTH); err != nil {
+		return fmt.Sprintf("Couldn't set the footer: %v.", err)
+	}
+	if !r.state.setFooter(r.id, footer, hidden) {
+		return NOT_SUBSCRIBED
+	}
+	switch {
+	case hidden:
+		return "The notifications in this chat won't have a footer anymore."
+	case footer == "":
+		return "The notifications in this chat will have the default footer."
+	}
+	return "The notifications in this chat will have your footer from now on."
+}
@@ -2,4 +2,4 @@ module chmllr.com/nns-proposals-bot
 
 go 1.17
 
-require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.4.0-beta.0 // indirect
+require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.4.0-beta.0
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var apiClient = http.Client{Timeout: 30 * time.Second}
+
+// Neuron ids are returned as strings by the governance API, but we store them as numbers.
+type neuronId uint64
+
+func (n *neuronId) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = neuronId(v)
+	return nil
+}
+
+// Vote values used in ballots.
+const (
+	VOTE_YES = 1
+	VOTE_NO  = 2
+)
+
+// Vote of a known neuron on a proposal.
+type ballot struct {
+	Id   neuronId `json:"id"`
+	Name string   `json:"name"`
+	Vote int      `json:"vote"`
+}
+
+// Voting power in e8s that voted on a proposal.
+type tally struct {
+	Yes   float64 `json:"yes"`
+	No    float64 `json:"no"`
+	Total float64 `json:"total"`
+}
+
+// Proposal as returned by the public governance API.
+type apiProposal struct {
+	Id       uint64          `json:"proposal_id"`
+	Title    string          `json:"title"`
+	Topic    string          `json:"topic"`
+	Summary  string          `json:"summary"`
+	Proposer neuronId        `json:"proposer"`
+	Status   string          `json:"status"`
+	Action   string          `json:"action"`
+	Deadline int64           `json:"deadline_timestamp_seconds"`
+	Created  int64           `json:"proposal_timestamp_seconds"`
+	Tally    tally           `json:"latest_tally"`
+	Ballots  []ballot        `json:"known_neurons_ballots"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+func (p apiProposal) toProposal() Proposal {
+	t := p.Tally
+	return Proposal{
+		Title:    p.Title,
+		Topic:    topicName(p.Topic),
+		Id:       p.Id,
+		Summary:  p.Summary,
+		Proposer: uint64(p.Proposer),
+		Status:   p.Status,
+		Deadline: p.Deadline,
+		Created:  p.Created,
+		Action:   p.Action,
+		Tally:    &t,
+		Payload:  formatPayload(p),
+	}
+}
+
+// Converts the API topic representation (e.g. `TOPIC_SUBNET_MANAGEMENT`) to the one used by the
+// proposal feed (e.g. `SubnetManagement`).
+func topicName(topic string) string {
+	var res string
+	for _, word := range strings.Split(strings.TrimPrefix(topic, "TOPIC_"), "_") {
+		if len(word) > 0 {
+			res += word[:1] + strings.ToLower(word[1:])
+		}
+	}
+	return res
+}
+
+// Fetches `path` from the governance API and decodes the JSON response into `v`.
+func getGovernanceAPI(path string, v interface{}) (err error) {
+	defer func(start time.Time) { metrics.observeRequest(SOURCE_GOVERNANCE_API, start, err) }(time.Now())
+	resp, err := apiClient.Get(GOVERNANCE_API_URL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Returns all proposals which are currently open for voting.
+func fetchOpenProposals() ([]Proposal, error) {
+	var resp struct {
+		Data []apiProposal `json:"data"`
+	}
+	if err := getGovernanceAPI("/proposals?include_status=OPEN&limit=100", &resp); err != nil {
+		return nil, err
+	}
+	var res []Proposal
+	for _, p := range resp.Data {
+		res = append(res, p.toProposal())
+	}
+	return res, nil
+}
+
+// Returns the id of the newest proposal.
+func fetchNewestProposalId() (uint64, error) {
+	var resp struct {
+		Data []apiProposal `json:"data"`
+	}
+	if err := getGovernanceAPI("/proposals?limit=1", &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Data) == 0 {
+		return 0, fmt.Errorf("no proposals returned")
+	}
+	return resp.Data[0].Id, nil
+}
+
+// Returns the current state of the proposal `id`.
+func fetchProposal(id uint64) (res apiProposal, err error) {
+	err = getGovernanceAPI(fmt.Sprintf("/proposals/%d", id), &res)
+	return
+}
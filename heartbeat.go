@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// URL of an external uptime monitor (e.g. a healthchecks.io check) which is pinged after every
+// successful poll, so the operator is alerted when the polls stop even if the process is alive.
+var HEARTBEAT_URL = ""
+
+var heartbeatClient = http.Client{Timeout: 10 * time.Second}
+
+// Pings HEARTBEAT_URL, if configured.
+func sendHeartbeat() {
+	if HEARTBEAT_URL == "" {
+		return
+	}
+	resp, err := heartbeatClient.Get(HEARTBEAT_URL)
+	if err != nil {
+		log.Println("Couldn't ping the heartbeat URL:", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Println("Couldn't ping the heartbeat URL: status", resp.Status)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Escapes the plain `text` for HTML and wraps all occurrences of `keywords` in bold tags, ignoring
+// case. Entities already in the text are kept.
+func highlight(text string, keywords []string) string {
+	text = html.UnescapeString(text)
+	if len(keywords) == 0 {
+		return html.EscapeString(text)
+	}
+	// Longer keywords first, so that they win over keywords they contain.
+	sorted := append([]string{}, keywords...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	var alternatives []string
+	for _, k := range sorted {
+		alternatives = append(alternatives, regexp.QuoteMeta(k))
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range regexp.MustCompile(`(?i)`+strings.Join(alternatives, "|")).FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]) + "<b>" + html.EscapeString(text[m[0]:m[1]]) + "</b>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// Returns the marker prepended to the title of proposals mentioning a highlight keyword of `chat`.
+func highlightMarker(proposal Proposal, chat Chat) string {
+	if len(chat.Highlights) > 0 && containsKeyword(proposal, chat.Highlights) {
+		return "🔦 "
+	}
+	return ""
+}
+
+func (s *State) addHighlight(id int64, keyword string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	for _, k := range chat.Highlights {
+		if strings.EqualFold(k, keyword) {
+			return nil
+		}
+	}
+	if len(chat.Highlights) >= MAX_KEYWORDS {
+		return fmt.Errorf("at most %d highlights are allowed", MAX_KEYWORDS)
+	}
+	chat.Highlights = append(chat.Highlights, keyword)
+	return nil
+}
+
+// Removes `keyword` from the highlights of chat `id`; an empty keyword removes all of them.
+func (s *State) removeHighlight(id int64, keyword string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return
+	}
+	var res []string
+	for _, k := range chat.Highlights {
+		if keyword != "" && !strings.EqualFold(k, keyword) {
+			res = append(res, k)
+		}
+	}
+	chat.Highlights = res
+}
+
+func (s *State) highlights(id int64) string {
+	chat, ok := s.chat(id)
+	if !ok {
+		return NOT_SUBSCRIBED
+	}
+	if len(chat.Highlights) == 0 {
+		return "You have no highlights. Add one with e.g. /highlight boundary node."
+	}
+	return "Highlighted keywords: " + strings.Join(chat.Highlights, ", ")
+}
+
+func highlightCommand(r *Request) string {
+	if len(r.args) == 0 {
+		return r.state.highlights(r.id)
+	}
+	keyword, ok := keywordArgument(r.args)
+	if !ok {
+		return "Please specify a keyword"
+	}
+	if err := r.state.addHighlight(r.id, keyword); err != nil {
+		return fmt.Sprintf("Couldn't add the highlight: %v", err)
+	}
+	return r.state.highlights(r.id)
+}
+
+func unhighlightCommand(r *Request) string {
+	if len(r.args) == 1 && r.args[0] == "all" {
+		r.state.removeHighlight(r.id, "")
+		return r.state.highlights(r.id)
+	}
+	keyword, ok := keywordArgument(r.args)
+	if !ok {
+		return "Please specify a keyword"
+	}
+	r.state.removeHighlight(r.id, keyword)
+	return r.state.highlights(r.id)
+}
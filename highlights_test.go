@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		keywords []string
+		want     string
+	}{
+		{"no keywords", "a < b & c", nil, "a &lt; b &amp; c"},
+		{"case-insensitive", "Upgrade the Registry canister", []string{"registry"}, "Upgrade the <b>Registry</b> canister"},
+		{"longer keyword first", "subnet rental and subnets", []string{"subnet", "subnet rental"}, "<b>subnet rental</b> and <b>subnet</b>s"},
+		{"not inside entities", "AT&amp;T and amp", []string{"amp"}, "AT&amp;T and <b>amp</b>"},
+		{"tags in the text are escaped", "<i>italic</i> text", []string{"text"}, "&lt;i&gt;italic&lt;/i&gt; <b>text</b>"},
+		{"keyword with special characters", "a <b> tag", []string{"<b>"}, "a <b>&lt;b&gt;</b> tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.text, tt.keywords); got != tt.want {
+				t.Errorf("highlight(%q, %q) = %q, want %q", tt.text, tt.keywords, got, tt.want)
+			}
+		})
+	}
+}
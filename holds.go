@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Delays of the fan-out per topic, during which a rejection or correction of a proposal takes effect
+// before anyone is notified.
+var TOPIC_DELAYS = topicDurations{}
+
+// Proposal whose fan-out is delayed until `Until`.
+type HeldProposal struct {
+	Proposal Proposal  `json:"proposal"`
+	Until    time.Time `json:"until"`
+}
+
+// Durations per topic, e.g. `SubnetManagement=10m,NodeAdmin=5m` as a flag value.
+type topicDurations map[string]time.Duration
+
+func (d topicDurations) String() string {
+	var entries []string
+	for topic, duration := range d {
+		entries = append(entries, fmt.Sprintf("%s=%s", topic, duration))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (d *topicDurations) Set(value string) error {
+	res := topicDurations{}
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("expected <topic>=<duration>, got %s", entry)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return err
+		}
+		res[strings.TrimSpace(parts[0])] = duration
+	}
+	*d = res
+	return nil
+}
+
+// Holds `proposal` back until `until`.
+func (s *State) hold(proposal Proposal, until time.Time) {
+	s.lock.Lock()
+	s.Held = append(s.Held, HeldProposal{proposal, until})
+	s.lock.Unlock()
+}
+
+// Removes and returns all proposals held until `now` or earlier.
+func (s *State) takeHeld(now time.Time) (res []Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var remaining []HeldProposal
+	for _, h := range s.Held {
+		if now.Before(h.Until) {
+			remaining = append(remaining, h)
+		} else {
+			res = append(res, h.Proposal)
+		}
+	}
+	s.Held = remaining
+	return
+}
+
+// Periodically releases the held proposals. A proposal which was rejected or failed in the
+// meantime is dropped; otherwise the current version from the governance API is announced, so
+// that corrections of the title or summary are included.
+func releaseHeldProposals(state *State) {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		for _, proposal := range state.takeHeld(time.Now()) {
+			current, err := currentVersionOf(proposal)
+			if err != nil {
+				log.Println("Couldn't fetch the held proposal", proposal.Id, ", announcing it as is:", err)
+				publishDiscovered(state, proposal)
+				continue
+			}
+			if current.Status == STATUS_REJECTED || current.Status == STATUS_FAILED {
+				log.Println("Suppressed the held proposal", proposal.Id, "as it was", current.Status)
+				state.cache(current)
+				continue
+			}
+			publishDiscovered(state, current)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aggregate statistics published on the public statistics page.
+type PublicStats struct {
+	Subscribers       int            `json:"subscribers"`
+	ProposalsThisWeek int            `json:"proposals_this_week"`
+	WindowDays        int            `json:"window_days"`
+	TopicVolumes      map[string]int `json:"topic_volumes"`
+	Generated         time.Time      `json:"generated"`
+}
+
+type topicVolume struct {
+	Topic string
+	Count int
+}
+
+// Returns the topic volumes sorted by count in descending order.
+func (p PublicStats) SortedTopics() (res []topicVolume) {
+	for topic, count := range p.TopicVolumes {
+		res = append(res, topicVolume{topic, count})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count == res[j].Count {
+			return res[i].Topic < res[j].Topic
+		}
+		return res[i].Count > res[j].Count
+	})
+	return
+}
+
+func (s *State) publicStats() PublicStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	stats := PublicStats{
+		Subscribers:  len(s.ChatIds),
+		WindowDays:   int(STATS_WINDOW.Hours() / 24),
+		TopicVolumes: map[string]int{},
+		Generated:    time.Now().UTC(),
+	}
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	for _, a := range s.Activity {
+		if a.Time.After(weekAgo) {
+			stats.ProposalsThisWeek++
+		}
+		stats.TopicVolumes[a.Topic]++
+	}
+	return stats
+}
+
+var statsPage = template.Must(template.New("stats").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>NNS Proposals Bot</title></head>
+<body>
+<h1>NNS Proposals Bot</h1>
+<p>Subscribers: {{.Subscribers}}</p>
+<p>Proposals relayed this week: {{.ProposalsThisWeek}}</p>
+<h2>Proposals per topic (last {{.WindowDays}} days)</h2>
+<table>
+{{range .SortedTopics}}<tr><td>{{.Topic}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<p><small>Generated {{.Generated.Format "2006-01-02 15:04 MST"}}. Also available as <a href="/stats.json">JSON</a>.</small></p>
+</body>
+</html>
+`))
+
+// Serves the public statistics page, the Prometheus metrics and the REST API on HTTP_ADDR.
+func serveHTTP(state *State) {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statsPage.Execute(w, state.publicStats()); err != nil {
+			log.Println("Couldn't render the statistics page:", err)
+		}
+	})
+	http.HandleFunc("/stats.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state.publicStats())
+	})
+	http.HandleFunc("/proposals/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/proposals/")
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, ".json"), 10, 64)
+		if err != nil || !strings.HasSuffix(name, ".json") {
+			http.NotFound(w, r)
+			return
+		}
+		proposal, ok := state.cachedProposal(id)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(proposal)
+	})
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.writePrometheus(w)
+	})
+	serveAPI(state)
+	log.Println("Serving HTTP on", HTTP_ADDR)
+	log.Fatal(http.ListenAndServe(HTTP_ADDR, nil))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Translations of the bot messages by language code, keyed by the English message. Messages
+// without a translation are sent in English.
+var catalogs = map[string]map[string]string{
+	"de": {
+		NOT_SUBSCRIBED: "Bitte starte den Bot zuerst mit /start.",
+		"Subscribed.":  "Abonniert.",
+		"Unsubscribed. If this was an accident, /restore brings back all your settings.": "Abbestellt. Falls das ein Versehen war, stellt /restore alle deine Einstellungen wieder her.",
+		"Use /keyboard on to get buttons for the most common actions.":                   "Mit /keyboard on erhältst du Schaltflächen für die häufigsten Aktionen.",
+		"Available commands:":                                           "Verfügbare Befehle:",
+		"Please specify one topic":                                      "Bitte gib genau ein Thema an",
+		"Please specify on or off":                                      "Bitte gib on oder off an",
+		"This command is only available in the admin chat.":             "Dieser Befehl ist nur im Admin-Chat verfügbar.",
+		"There are no open proposals matching your filters.":            "Es gibt keine offenen Vorschläge, die deinen Filtern entsprechen.",
+		"There are no recent proposals matching your filters.":          "Es gibt keine neuen Vorschläge, die deinen Filtern entsprechen.",
+		"The bot will answer in English from now on.":                   "Der Bot antwortet ab jetzt auf Deutsch.",
+		"subscribe to the notifications":                                "die Benachrichtigungen abonnieren",
+		"unsubscribe":                                                   "abbestellen",
+		"subscribe again with the settings from before /stop":           "erneut abonnieren, mit den Einstellungen von vor /stop",
+		"pause the notifications while keeping your settings":           "die Benachrichtigungen pausieren, die Einstellungen bleiben erhalten",
+		"resume the notifications, optionally skipping what you missed": "die Benachrichtigungen fortsetzen, optional ohne das Verpasste",
+		"block proposals with a topic, e.g. /block #ExchangeRate":       "Vorschläge eines Themas blockieren, z.B. /block #ExchangeRate",
+		"list the topics of all proposals seen so far":                  "die Themen aller bisher gesehenen Vorschläge auflisten",
+		"unblock proposals with a topic":                                "Vorschläge eines Themas wieder zulassen",
+		"display the list of blocked topics":                            "die blockierten Themen anzeigen",
+		"list the most recent proposals matching your filters":          "die neuesten Vorschläge anzeigen, die deinen Filtern entsprechen",
+		"show a proposal":                                               "einen Vorschlag anzeigen",
+		"search the recent proposals":                                   "die neuesten Vorschläge durchsuchen",
+		"list the open proposals sorted by voting deadline":             "die offenen Vorschläge nach Abstimmungsfrist sortiert anzeigen",
+		"show this message":                                             "diese Nachricht anzeigen",
+		"choose the language of the bot messages":                       "die Sprache der Bot-Nachrichten wählen",
+	},
+	"fr": {
+		NOT_SUBSCRIBED: "Veuillez d'abord démarrer le bot avec /start.",
+		"Subscribed.":  "Abonné.",
+		"Unsubscribed. If this was an accident, /restore brings back all your settings.": "Désabonné. Si c'était une erreur, /restore rétablit tous vos paramètres.",
+		"Use /keyboard on to get buttons for the most common actions.":                   "Utilisez /keyboard on pour obtenir des boutons pour les actions les plus courantes.",
+		"Available commands:":                                           "Commandes disponibles :",
+		"Please specify one topic":                                      "Veuillez indiquer un seul thème",
+		"Please specify on or off":                                      "Veuillez indiquer on ou off",
+		"This command is only available in the admin chat.":             "Cette commande n'est disponible que dans le chat d'administration.",
+		"There are no open proposals matching your filters.":            "Aucune proposition ouverte ne correspond à vos filtres.",
+		"There are no recent proposals matching your filters.":          "Aucune proposition récente ne correspond à vos filtres.",
+		"The bot will answer in English from now on.":                   "Le bot répondra désormais en français.",
+		"subscribe to the notifications":                                "s'abonner aux notifications",
+		"unsubscribe":                                                   "se désabonner",
+		"subscribe again with the settings from before /stop":           "se réabonner avec les paramètres d'avant /stop",
+		"pause the notifications while keeping your settings":           "suspendre les notifications en gardant vos paramètres",
+		"resume the notifications, optionally skipping what you missed": "reprendre les notifications, en ignorant éventuellement ce que vous avez manqué",
+		"block proposals with a topic, e.g. /block #ExchangeRate":       "bloquer les propositions d'un thème, p. ex. /block #ExchangeRate",
+		"list the topics of all proposals seen so far":                  "lister les thèmes de toutes les propositions vues jusqu'ici",
+		"unblock proposals with a topic":                                "débloquer les propositions d'un thème",
+		"display the list of blocked topics":                            "afficher la liste des thèmes bloqués",
+		"list the most recent proposals matching your filters":          "lister les propositions les plus récentes correspondant à vos filtres",
+		"show a proposal":                                               "afficher une proposition",
+		"search the recent proposals":                                   "rechercher dans les propositions récentes",
+		"list the open proposals sorted by voting deadline":             "lister les propositions ouvertes par date limite de vote",
+		"show this message":                                             "afficher ce message",
+		"choose the language of the bot messages":                       "choisir la langue des messages du bot",
+	},
+	"es": {
+		NOT_SUBSCRIBED: "Primero inicia el bot con /start.",
+		"Subscribed.":  "Suscrito.",
+		"Unsubscribed. If this was an accident, /restore brings back all your settings.": "Suscripción cancelada. Si fue un error, /restore recupera toda tu configuración.",
+		"Use /keyboard on to get buttons for the most common actions.":                   "Usa /keyboard on para obtener botones para las acciones más comunes.",
+		"Available commands:":                                           "Comandos disponibles:",
+		"Please specify one topic":                                      "Indica un solo tema",
+		"Please specify on or off":                                      "Indica on u off",
+		"This command is only available in the admin chat.":             "Este comando solo está disponible en el chat de administración.",
+		"There are no open proposals matching your filters.":            "No hay propuestas abiertas que coincidan con tus filtros.",
+		"There are no recent proposals matching your filters.":          "No hay propuestas recientes que coincidan con tus filtros.",
+		"The bot will answer in English from now on.":                   "A partir de ahora, el bot responderá en español.",
+		"subscribe to the notifications":                                "suscribirse a las notificaciones",
+		"unsubscribe":                                                   "cancelar la suscripción",
+		"subscribe again with the settings from before /stop":           "suscribirse de nuevo con la configuración de antes de /stop",
+		"pause the notifications while keeping your settings":           "pausar las notificaciones conservando tu configuración",
+		"resume the notifications, optionally skipping what you missed": "reanudar las notificaciones, opcionalmente sin lo que te perdiste",
+		"block proposals with a topic, e.g. /block #ExchangeRate":       "bloquear las propuestas de un tema, p. ej. /block #ExchangeRate",
+		"list the topics of all proposals seen so far":                  "listar los temas de todas las propuestas vistas hasta ahora",
+		"unblock proposals with a topic":                                "desbloquear las propuestas de un tema",
+		"display the list of blocked topics":                            "mostrar la lista de temas bloqueados",
+		"list the most recent proposals matching your filters":          "listar las propuestas más recientes que coinciden con tus filtros",
+		"show a proposal":                                               "mostrar una propuesta",
+		"search the recent proposals":                                   "buscar en las propuestas recientes",
+		"list the open proposals sorted by voting deadline":             "listar las propuestas abiertas por fecha límite de votación",
+		"show this message":                                             "mostrar este mensaje",
+		"choose the language of the bot messages":                       "elegir el idioma de los mensajes del bot",
+	},
+	"it": {
+		NOT_SUBSCRIBED: "Avvia prima il bot con /start.",
+		"Subscribed.":  "Iscritto.",
+		"Unsubscribed. If this was an accident, /restore brings back all your settings.": "Iscrizione annullata. Se è stato un errore, /restore ripristina tutte le tue impostazioni.",
+		"Use /keyboard on to get buttons for the most common actions.":                   "Usa /keyboard on per avere i pulsanti delle azioni più comuni.",
+		"Available commands:":                                           "Comandi disponibili:",
+		"Please specify one topic":                                      "Indica un solo tema",
+		"Please specify on or off":                                      "Indica on oppure off",
+		"This command is only available in the admin chat.":             "Questo comando è disponibile solo nella chat di amministrazione.",
+		"There are no open proposals matching your filters.":            "Non ci sono proposte aperte che corrispondono ai tuoi filtri.",
+		"There are no recent proposals matching your filters.":          "Non ci sono proposte recenti che corrispondono ai tuoi filtri.",
+		"The bot will answer in English from now on.":                   "Da ora in poi il bot risponderà in italiano.",
+		"subscribe to the notifications":                                "iscriversi alle notifiche",
+		"unsubscribe":                                                   "annullare l'iscrizione",
+		"subscribe again with the settings from before /stop":           "iscriversi di nuovo con le impostazioni di prima di /stop",
+		"pause the notifications while keeping your settings":           "sospendere le notifiche mantenendo le impostazioni",
+		"resume the notifications, optionally skipping what you missed": "riprendere le notifiche, eventualmente saltando quelle perse",
+		"block proposals with a topic, e.g. /block #ExchangeRate":       "bloccare le proposte di un tema, ad es. /block #ExchangeRate",
+		"list the topics of all proposals seen so far":                  "elencare i temi di tutte le proposte viste finora",
+		"unblock proposals with a topic":                                "sbloccare le proposte di un tema",
+		"display the list of blocked topics":                            "mostrare l'elenco dei temi bloccati",
+		"list the most recent proposals matching your filters":          "elencare le proposte più recenti che corrispondono ai tuoi filtri",
+		"show a proposal":                                               "mostrare una proposta",
+		"search the recent proposals":                                   "cercare tra le proposte recenti",
+		"list the open proposals sorted by voting deadline":             "elencare le proposte aperte per scadenza della votazione",
+		"show this message":                                             "mostrare questo messaggio",
+		"choose the language of the bot messages":                       "scegliere la lingua dei messaggi del bot",
+	},
+}
+
+// Names of the supported languages in the language itself, for /language.
+var languageNames = map[string]string{"en": "English", "de": "Deutsch", "fr": "Français", "es": "Español", "it": "Italiano"}
+
+// Returns the translation of the English `text` into `lang`, or `text` if there is none.
+func translate(lang, text string) string {
+	if translated, ok := catalogs[lang][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// Returns the language of chat `id`.
+func language(state *State, id int64) string {
+	chat, _ := state.chat(id)
+	return chat.Language
+}
+
+// Translates `text` into the language of the chat of the request.
+func (r *Request) tr(text string) string {
+	return translate(language(r.state, r.id), text)
+}
+
+func (s *State) setLanguage(id int64, lang string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Language = lang
+	return true
+}
+
+func languageCommand(r *Request) string {
+	var codes []string
+	for code, name := range languageNames {
+		codes = append(codes, fmt.Sprintf("%s (%s)", code, name))
+	}
+	sort.Strings(codes)
+	if len(r.args) != 1 || languageNames[r.args[0]] == "" {
+		return "Please choose one of the languages " + strings.Join(codes, ", ") + ", e.g. /language de"
+	}
+	lang := r.args[0]
+	// English is the default.
+	if lang == "en" {
+		lang = ""
+	}
+	if !r.state.setLanguage(r.id, lang) {
+		return NOT_SUBSCRIBED
+	}
+	return translate(lang, "The bot will answer in English from now on.")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Subscription of a chat in an import file.
+type importRow struct {
+	ChatId        int64    `json:"chat_id"`
+	BlockedTopics []string `json:"blocked_topics"`
+	OnlyTopics    []string `json:"only_topics"`
+}
+
+// Reads the subscriptions from a JSON file with a list of rows or a CSV file with the columns
+// chat_id, blocked_topics and only_topics, where topics are separated by semicolons.
+func readImportFile(path string) ([]importRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []importRow
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.NewDecoder(f).Decode(&rows)
+		return rows, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["chat_id"]; !ok {
+		return nil, fmt.Errorf("the header has no chat_id column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	topics := func(value string) (res []string) {
+		for _, topic := range strings.Split(value, ";") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				res = append(res, topic)
+			}
+		}
+		return
+	}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Unparseable ids are reported as invalid rows by the validation.
+		id, _ := strconv.ParseInt(field(record, "chat_id"), 10, 64)
+		rows = append(rows, importRow{id, topics(field(record, "blocked_topics")), topics(field(record, "only_topics"))})
+	}
+}
+
+// Returns the reason why `row` can't be imported, if any.
+func validateImportRow(row importRow) error {
+	if row.ChatId == 0 {
+		return fmt.Errorf("missing or invalid chat id")
+	}
+	if len(row.BlockedTopics) > 0 && len(row.OnlyTopics) > 0 {
+		return fmt.Errorf("blocked topics and only topics are mutually exclusive")
+	}
+	if len(row.BlockedTopics) > MAX_BLOCKED_TOPICS || len(row.OnlyTopics) > MAX_BLOCKED_TOPICS {
+		return fmt.Errorf("more than %d topics", MAX_BLOCKED_TOPICS)
+	}
+	for _, topic := range append(row.BlockedTopics, row.OnlyTopics...) {
+		if len(topic) > MAX_TOPIC_LENGTH {
+			return fmt.Errorf("topic %q is longer than %d characters", topic, MAX_TOPIC_LENGTH)
+		}
+	}
+	return nil
+}
+
+func (row importRow) chat() *Chat {
+	chat := &Chat{BlockedTopics: map[string]bool{}}
+	for _, topic := range row.BlockedTopics {
+		chat.BlockedTopics[topic] = true
+	}
+	if len(row.OnlyTopics) > 0 {
+		chat.FilterMode, chat.OnlyTopics = FILTER_ONLY, map[string]bool{}
+		for _, topic := range row.OnlyTopics {
+			chat.OnlyTopics[topic] = true
+		}
+	}
+	return chat
+}
+
+// Adds the subscriptions in `rows` to the state. Invalid rows, duplicate rows and chats which
+// are already subscribed with different settings are skipped and reported. Returns the number of
+// imported chats and the report.
+func (s *State) importSubscriptions(rows []importRow) (imported int, report []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	seen := map[int64]int{}
+	for i, row := range rows {
+		// Rows are numbered from 1, not counting the CSV header.
+		n := i + 1
+		if err := validateImportRow(row); err != nil {
+			report = append(report, fmt.Sprintf("row %d: %v", n, err))
+			continue
+		}
+		if first, ok := seen[row.ChatId]; ok {
+			report = append(report, fmt.Sprintf("row %d: chat %d was already imported in row %d", n, row.ChatId, first))
+			continue
+		}
+		seen[row.ChatId] = n
+		chat := row.chat()
+		if existing := s.ChatIds[row.ChatId]; existing != nil {
+			if existing.FilterMode != chat.FilterMode || !reflect.DeepEqual(sortedKeys(existing.BlockedTopics), sortedKeys(chat.BlockedTopics)) ||
+				!reflect.DeepEqual(sortedKeys(existing.OnlyTopics), sortedKeys(chat.OnlyTopics)) {
+				report = append(report, fmt.Sprintf("row %d: chat %d is already subscribed with different settings, keeping them", n, row.ChatId))
+			}
+			continue
+		}
+		s.ChatIds[row.ChatId] = chat
+		imported++
+	}
+	return
+}
+
+// Imports the subscriptions in the file at `path` into the persisted state. The bot must not
+// run at the same time, as it would overwrite the state.
+func runImport(path string) {
+	rows, err := readImportFile(path)
+	if err != nil {
+		log.Fatalln("Couldn't read the import file", path, ":", err)
+	}
+	var state State
+	state.restore()
+	imported, report := state.importSubscriptions(rows)
+	for _, line := range report {
+		fmt.Println(line)
+	}
+	state.persist()
+	fmt.Printf("Imported %d of %d rows into %s\n", imported, len(rows), STATE_PATH)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadImportFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []importRow
+		wantErr bool
+	}{
+		{"csv", "chats.csv", "chat_id,blocked_topics,only_topics\n42,ExchangeRate; NodeAdmin,\n-100,,Governance\n",
+			[]importRow{{42, []string{"ExchangeRate", "NodeAdmin"}, nil}, {-100, nil, []string{"Governance"}}}, false},
+		{"csv with reordered and missing columns", "chats.csv", " only_topics , chat_id\nGovernance,7\n",
+			[]importRow{{7, nil, []string{"Governance"}}}, false},
+		{"csv with short rows", "chats.csv", "chat_id,blocked_topics\n7\n", []importRow{{7, nil, nil}}, false},
+		{"csv with an invalid id", "chats.csv", "chat_id\nabc\n", []importRow{{0, nil, nil}}, false},
+		{"csv without chat_id", "chats.csv", "id,blocked_topics\n7,\n", nil, true},
+		{"empty csv", "chats.csv", "", nil, true},
+		{"json", "chats.JSON", `[{"chat_id": 42, "blocked_topics": ["ExchangeRate"]}, {"chat_id": 7, "only_topics": ["Governance"]}]`,
+			[]importRow{{42, []string{"ExchangeRate"}, nil}, {7, nil, []string{"Governance"}}}, false},
+		{"invalid json", "chats.json", `{"chat_id": 42}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readImportFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readImportFile() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readImportFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	tooMany := make([]string, MAX_BLOCKED_TOPICS+1)
+	for i := range tooMany {
+		tooMany[i] = "Topic"
+	}
+	tests := []struct {
+		name    string
+		row     importRow
+		wantErr bool
+	}{
+		{"blocked topics", importRow{42, []string{"ExchangeRate"}, nil}, false},
+		{"only topics", importRow{-100, nil, []string{"Governance"}}, false},
+		{"no topics", importRow{42, nil, nil}, false},
+		{"missing chat id", importRow{0, []string{"ExchangeRate"}, nil}, true},
+		{"blocked and only topics", importRow{42, []string{"ExchangeRate"}, []string{"Governance"}}, true},
+		{"too many topics", importRow{42, tooMany, nil}, true},
+		{"topic too long", importRow{42, nil, []string{strings.Repeat("x", MAX_TOPIC_LENGTH+1)}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateImportRow(tt.row); (err != nil) != tt.wantErr {
+				t.Errorf("validateImportRow() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
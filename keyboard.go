@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Rows of the quick action keyboard offered in private chats, mapping the button labels to the
+// commands they trigger.
+var keyboardRows = [][]struct{ Label, Command string }{
+	{{"Blacklist", "/blacklist"}, {"Governance only", "/governance_only"}},
+	{{"Daily digest", "/digest daily"}, {"Help", "/help"}},
+}
+
+// Returns the command triggered by the keyboard button with the label `text`, if any.
+func buttonCommand(text string) (string, bool) {
+	for _, row := range keyboardRows {
+		for _, button := range row {
+			if button.Label == text {
+				return button.Command, true
+			}
+		}
+	}
+	return "", false
+}
+
+func quickActionKeyboard() tgbotapi.ReplyKeyboardMarkup {
+	var rows [][]tgbotapi.KeyboardButton
+	for _, row := range keyboardRows {
+		var buttons []tgbotapi.KeyboardButton
+		for _, button := range row {
+			buttons = append(buttons, tgbotapi.NewKeyboardButton(button.Label))
+		}
+		rows = append(rows, buttons)
+	}
+	keyboard := tgbotapi.NewReplyKeyboard(rows...)
+	keyboard.ResizeKeyboard = true
+	return keyboard
+}
+
+func keyboardCommand(r *Request) string {
+	if !r.message.Chat.IsPrivate() {
+		return "The keyboard is only available in private chats."
+	}
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	msg := tgbotapi.NewMessage(r.id, "The keyboard was removed; use /keyboard on to get it back.")
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	if enabled {
+		msg.Text = "Use the buttons below for the most common actions; /keyboard off removes them."
+		msg.ReplyMarkup = quickActionKeyboard()
+	}
+	if _, err := r.bot.Send(msg); err != nil {
+		log.Println("Couldn't send the keyboard to", r.id, ":", err)
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns true if the title or summary of `proposal` contains one of `keywords`, ignoring case.
+func containsKeyword(proposal Proposal, keywords []string) bool {
+	content := strings.ToLower(proposal.Title + " " + proposal.Summary)
+	for _, keyword := range keywords {
+		if strings.Contains(content, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if `proposal` passes the keyword filters of `chat`: it has to contain one of the
+// filter keywords, if any, and none of the blocked keywords.
+func acceptsKeywords(chat *Chat, proposal Proposal) bool {
+	if len(chat.Keywords) > 0 && !containsKeyword(proposal, chat.Keywords) {
+		return false
+	}
+	return !containsKeyword(proposal, chat.BlockedKeywords)
+}
+
+// Adds `keyword` to the list selected by `blocked` of chat `id`.
+func (s *State) addKeyword(id int64, keyword string, blocked bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	list := &chat.Keywords
+	if blocked {
+		list = &chat.BlockedKeywords
+	}
+	for _, k := range *list {
+		if strings.EqualFold(k, keyword) {
+			return nil
+		}
+	}
+	if len(*list) >= MAX_KEYWORDS {
+		return fmt.Errorf("at most %d keywords are allowed", MAX_KEYWORDS)
+	}
+	*list = append(*list, keyword)
+	return nil
+}
+
+// Removes `keyword` from the list selected by `blocked` of chat `id`; an empty keyword clears
+// the list.
+func (s *State) removeKeyword(id int64, keyword string, blocked bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return
+	}
+	list := &chat.Keywords
+	if blocked {
+		list = &chat.BlockedKeywords
+	}
+	var res []string
+	for _, k := range *list {
+		if keyword != "" && !strings.EqualFold(k, keyword) {
+			res = append(res, k)
+		}
+	}
+	*list = res
+}
+
+// Returns the keyword filters of chat `id`.
+func (s *State) keywordFilters(id int64) string {
+	chat, _ := s.chat(id)
+	keywords, blocked := "none", "none"
+	if len(chat.Keywords) > 0 {
+		keywords = strings.Join(chat.Keywords, ", ")
+	}
+	if len(chat.BlockedKeywords) > 0 {
+		blocked = strings.Join(chat.BlockedKeywords, ", ")
+	}
+	return fmt.Sprintf("Required keywords (any of): %s\nBlocked keywords: %s", keywords, blocked)
+}
+
+// Returns the keyword passed as arguments; keywords may consist of several words.
+func keywordArgument(args []string) (string, bool) {
+	keyword := strings.Join(args, " ")
+	return keyword, keyword != "" && len(keyword) <= MAX_TOPIC_LENGTH
+}
+
+func filterKeywordCommand(r *Request) string {
+	if len(r.args) == 1 && r.args[0] == "off" {
+		r.state.removeKeyword(r.id, "", false)
+		return r.state.keywordFilters(r.id)
+	}
+	keyword, ok := keywordArgument(r.args)
+	if !ok {
+		return "Please specify a keyword"
+	}
+	if err := r.state.addKeyword(r.id, keyword, false); err != nil {
+		return "Couldn't add the keyword: " + err.Error() + "."
+	}
+	return r.state.keywordFilters(r.id)
+}
+
+func blockKeywordCommand(r *Request) string {
+	keyword, ok := keywordArgument(r.args)
+	if !ok {
+		return "Please specify a keyword"
+	}
+	if err := r.state.addKeyword(r.id, keyword, true); err != nil {
+		return "Couldn't block the keyword: " + err.Error() + "."
+	}
+	return r.state.keywordFilters(r.id)
+}
+
+func unblockKeywordCommand(r *Request) string {
+	keyword, ok := keywordArgument(r.args)
+	if !ok {
+		return "Please specify a keyword"
+	}
+	r.state.removeKeyword(r.id, keyword, true)
+	return r.state.keywordFilters(r.id)
+}
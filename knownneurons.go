@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var KNOWN_NEURONS_REFRESH_INTERVAL = 24 * time.Hour
+
+// Neuron registered as known neuron in the NNS.
+type knownNeuron struct {
+	Id   neuronId `json:"id"`
+	Name string   `json:"name"`
+}
+
+// Names of the known neurons by id, refreshed from the governance API every
+// KNOWN_NEURONS_REFRESH_INTERVAL.
+type KnownNeurons struct {
+	names map[uint64]string
+	lock  sync.RWMutex
+}
+
+var knownNeurons = KnownNeurons{names: map[uint64]string{}}
+
+// Returns the name of the known neuron `id`, or an empty string if it isn't registered.
+func (k *KnownNeurons) name(id uint64) string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.names[id]
+}
+
+// Replaces the names of the known neurons with the ones currently registered in the NNS.
+func refreshKnownNeurons() {
+	var resp struct {
+		Data []knownNeuron `json:"data"`
+	}
+	if err := getGovernanceAPI("/known-neurons", &resp); err != nil {
+		log.Println("Couldn't fetch the known neurons:", err)
+		return
+	}
+	names := map[uint64]string{}
+	for _, n := range resp.Data {
+		if n.Name != "" {
+			names[uint64(n.Id)] = n.Name
+		}
+	}
+	knownNeurons.lock.Lock()
+	knownNeurons.names = names
+	knownNeurons.lock.Unlock()
+	log.Println("Fetched the names of", len(names), "known neurons")
+}
+
+// Returns the neuron `id` with its name if it's a known neuron, e.g. "DFINITY Foundation (27)".
+func proposerName(id uint64) string {
+	if name := knownNeurons.name(id); name != "" {
+		return fmt.Sprintf("%s (%d)", name, id)
+	}
+	return fmt.Sprint(id)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Stages of the end-to-end notification latency.
+const (
+	// From the creation of a proposal to its discovery by the bot.
+	STAGE_DISCOVERY = "discovery"
+	// From the discovery of a proposal to its delivery to a chat.
+	STAGE_DELIVERY = "delivery"
+	// From the creation of a proposal to its delivery to a chat.
+	STAGE_END_TO_END = "end_to_end"
+)
+
+// Time for which the discovery of a proposal is remembered to measure the latency of its
+// deliveries, long enough for the daily digest and the catch-up after a delivery window.
+var LATENCY_RETENTION = 25 * time.Hour
+
+type latencyMetrics struct {
+	Count int
+	Total time.Duration
+	Last  time.Duration
+	Max   time.Duration
+}
+
+// Discovery of a proposal: when it was created and when the bot found it.
+type discovery struct {
+	created, discovered time.Time
+}
+
+func (l *latencyMetrics) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.Count++
+	l.Total += d
+	l.Last = d
+	if d > l.Max {
+		l.Max = d
+	}
+}
+
+// Expects the lock to be held.
+func (m *Metrics) latencyOf(stage string) *latencyMetrics {
+	if m.latencies[stage] == nil {
+		m.latencies[stage] = &latencyMetrics{}
+	}
+	return m.latencies[stage]
+}
+
+// Records the discovery of `proposal` and forgets the discoveries older than LATENCY_RETENTION.
+func (m *Metrics) observeDiscovery(proposal Proposal) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	for id, d := range m.discoveries {
+		if now.Sub(d.discovered) > LATENCY_RETENTION {
+			delete(m.discoveries, id)
+		}
+	}
+	d := discovery{discovered: now}
+	if proposal.Created > 0 {
+		d.created = time.Unix(proposal.Created, 0)
+		m.latencyOf(STAGE_DISCOVERY).observe(now.Sub(d.created))
+	}
+	m.discoveries[proposal.Id] = d
+}
+
+// Records the delivery of the notification about proposal `proposalId` of the governance system
+// `source` to a chat. Only the discoveries of NNS proposals are recorded.
+func (m *Metrics) observeDelivery(source string, proposalId uint64) {
+	if source != "" {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	d, ok := m.discoveries[proposalId]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	m.latencyOf(STAGE_DELIVERY).observe(now.Sub(d.discovered))
+	if !d.created.IsZero() {
+		m.latencyOf(STAGE_END_TO_END).observe(now.Sub(d.created))
+	}
+}
+
+// Returns a line per stage for /status. Expects the lock to be held.
+func (m *Metrics) latencyStatus() (lines []string) {
+	for _, stage := range []string{STAGE_DISCOVERY, STAGE_DELIVERY, STAGE_END_TO_END} {
+		l := m.latencies[stage]
+		if l == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("Latency (%s): %s (average %s, max %s, %d samples)", stage,
+			l.Last.Round(time.Second), (l.Total/time.Duration(l.Count)).Round(time.Second), l.Max.Round(time.Second), l.Count))
+	}
+	return
+}
+
+// Writes the latencies as a Prometheus summary without quantiles. Expects the lock to be held.
+func (m *Metrics) writeLatencies(w io.Writer) {
+	name := "nns_notification_latency_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of the notifications by stage.\n# TYPE %s summary\n", name, name)
+	for _, stage := range []string{STAGE_DISCOVERY, STAGE_DELIVERY, STAGE_END_TO_END} {
+		if l := m.latencies[stage]; l != nil {
+			fmt.Fprintf(w, "%s_sum{stage=%q} %g\n%s_count{stage=%q} %d\n", name, stage, l.Total.Seconds(), name, stage, l.Count)
+		}
+	}
+}
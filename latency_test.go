@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserveDelivery(t *testing.T) {
+	m := Metrics{latencies: map[string]*latencyMetrics{}, discoveries: map[uint64]discovery{}}
+	m.observeDiscovery(Proposal{Id: 7, Created: time.Now().Add(-time.Hour).Unix()})
+	m.observeDelivery("", 7)
+	// An SNS proposal with the same id and a proposal which wasn't discovered aren't counted.
+	m.observeDelivery("sns-1", 7)
+	m.observeDelivery("", 8)
+	for _, stage := range []string{STAGE_DISCOVERY, STAGE_DELIVERY, STAGE_END_TO_END} {
+		if l := m.latencies[stage]; l == nil || l.Count != 1 {
+			t.Errorf("latency of stage %s = %+v, want one sample", stage, l)
+		}
+	}
+	if l := m.latencies[STAGE_END_TO_END]; l != nil && l.Last < time.Hour {
+		t.Errorf("end to end latency = %s, want at least an hour", l.Last)
+	}
+}
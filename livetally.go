@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (s *State) setLiveTally(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.LiveTally = enabled
+	return true
+}
+
+// Returns the tally last shown in the notification about `proposalId` in chat `id`.
+func (s *State) shownTally(id int64, proposalId uint64) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		for _, d := range chat.Deliveries {
+			if d.of("", proposalId) {
+				return d.Tally
+			}
+		}
+	}
+	return ""
+}
+
+func (s *State) setShownTally(id int64, proposalId uint64, tally string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.delivery(proposalId).Tally = tally
+	}
+}
+
+// Edits the notifications about `proposal` in the chats with live tallies to show its current
+// status, tally and attestations. Notifications which had to be split into several messages are left as they are.
+func refreshLiveTally(shards *Shards, state *State, proposal Proposal) {
+	if proposal.Tally == nil {
+		return
+	}
+	line := "📊 " + formatTally(*proposal.Tally)
+	for id, messageId := range state.recipients(proposal) {
+		chat, ok := state.chat(id)
+		if !ok || !chat.LiveTally || state.shownTally(id, proposal.Id) == line+proposal.Status {
+			continue
+		}
+		text := renderEdited(state, proposal, chat)
+		if len(text) > MAX_MESSAGE_LENGTH {
+			continue
+		}
+		edit := tgbotapi.NewEditMessageText(id, messageId, text)
+		edit.ParseMode = tgbotapi.ModeHTML
+		edit.DisableWebPagePreview = true
+		// Editing the text drops the keyboard unless it's sent again.
+		if markup, ok := notificationKeyboard(state, proposal, chat); ok {
+			edit.ReplyMarkup = &markup
+		}
+		if err := editMessage(shards, edit); err != nil {
+			log.Println("Couldn't update the tally of proposal", proposal.Id, "in", id, ":", err)
+			continue
+		}
+		state.setShownTally(id, proposal.Id, line+proposal.Status)
+	}
+}
+
+// Refreshes the live tallies of all open proposals; scheduled every TALLY_REFRESH_INTERVAL.
+func refreshLiveTallies(shards *Shards, state *State) {
+	for _, proposal := range state.trackedProposals() {
+		refreshLiveTally(shards, state, proposal)
+	}
+}
+
+func liveTallyCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /live_tally on or /live_tally off"
+	}
+	if !r.state.setLiveTally(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "The notifications about open proposals will show the current tally from now on."
+	}
+	return "Live tallies disabled."
+}
@@ -2,14 +2,16 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
+	"flag"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -17,14 +19,76 @@ import (
 
 var (
 	URL                        = "https://cb3bp-ciaaa-aaaai-qkw4q-cai.raw.ic0.app"
+	GOVERNANCE_API_URL         = "https://ic-api.internetcomputer.org/api/v3"
 	STATE_PATH                 = "state.json"
 	NNS_POLL_INTERVALL         = 5 * time.Minute
 	STATE_PERSISTENCE_INTERVAL = 5 * time.Minute
 	MAX_TOPIC_LENGTH           = 50
+	MAX_TITLE_LENGTH           = 256
 	MAX_BLOCKED_TOPICS         = 30
+	MAX_KEYWORDS               = 20
+	MIN_SUMMARY_LENGTH         = 100
 	MAX_SUMMARY_LENGTH         = 2048
+	DEFAULT_SUMMARY_LENGTH     = 500
 	TOPIC_GOVERNANCE           = "Governance"
 	ALL_EXCEPT_GOVERNANCE      = "AllButGovernance"
+	FILTER_ONLY                = "only"
+	PROPOSAL_URL_TEMPLATE      = "https://nns.ic0.app/proposal/?proposal={id}"
+	ARCHIVE_CHANNEL_ID         = getEnvInt("ARCHIVE_CHANNEL_ID")
+	ADMIN_CHAT_ID              = getEnvInt("ADMIN_CHAT_ID")
+	MIRROR_MODE                = false
+	MIRROR_CHANNEL_IDS         = getEnvInts("MIRROR_CHANNEL_IDS")
+	SELF_TEST_CHAT_ID          = getEnvInt("SELF_TEST_CHAT_ID")
+	SELF_TEST_INTERVAL         = 6 * time.Hour
+	HTTP_ADDR                  = ""
+	TELEMETRY_URL              = ""
+	TELEMETRY_INTERVAL         = 24 * time.Hour
+	VERIFY_ARTIFACTS           = false
+	MAX_ARTIFACT_SIZE          = int64(512 << 20)
+	ARTIFACT_DOWNLOAD_TIMEOUT  = 10 * time.Minute
+	STATUS_POLL_INTERVAL       = 10 * time.Minute
+	STATUS_OPEN                = "OPEN"
+	STATUS_REJECTED            = "REJECTED"
+	STATUS_ADOPTED             = "ADOPTED"
+	STATUS_EXECUTED            = "EXECUTED"
+	STATUS_FAILED              = "FAILED"
+	DECIDING_SOON              = 24 * time.Hour
+	FORMAT_FULL                = "full"
+	FORMAT_COMPACT             = "compact"
+	STATS_WINDOW               = 30 * 24 * time.Hour
+	LEADERBOARD_SIZE           = 5
+	MAX_DEFERRED_PROPOSALS     = 50
+	MAX_DELIVERY_RECORDS       = 100
+	PERMISSION_PROBE_INTERVAL  = time.Hour
+	TRANSFER_CODE_TTL          = time.Hour
+	UNREACHABLE_GRACE          = 3 * 24 * time.Hour
+	TOMBSTONE_RETENTION        = 30 * 24 * time.Hour
+	UNREACHABLE_RETRY_INTERVAL = 12 * time.Hour
+	MIN_FAILED_ATTEMPTS        = 4
+	MAX_SEND_ATTEMPTS          = 3
+	MAX_MESSAGE_LENGTH         = 4096
+	MAX_PART_PREFIX_LENGTH     = 10
+	MAX_CATCHUP_PROPOSALS      = uint64(50)
+	DOWNTIME_NOTICE_THRESHOLD  = 15 * time.Minute
+	DEDUP_WINDOW               = 6 * time.Hour
+	MAX_DIFF_LINES             = 30
+	MAX_CACHED_PROPOSALS       = 1000
+	DEFAULT_LAST_PROPOSALS     = 5
+	MAX_LAST_PROPOSALS         = 20
+	MAX_SEARCH_RESULTS         = 10
+	MAX_WATCHED_PROPOSALS      = 20
+	MAX_QUEUE_LENGTH           = 10000
+	QUEUE_PAUSE_THRESHOLD      = 1000
+	FRESHNESS_SLO              = 15 * time.Minute
+	DEEP_BACKFILL_THRESHOLD    = 6 * time.Hour
+	MAX_BACKFILL_PROPOSALS     = uint64(500)
+	REWARD_CHECK_INTERVAL      = 24 * time.Hour
+	REWARD_REMINDER_INTERVAL   = 7 * 24 * time.Hour
+	REWARD_WINDOW              = 7 * 24 * time.Hour
+	DIGEST_HOUR                = 8
+	HELP_THROTTLE_INTERVAL     = time.Minute
+	RECAP_WEEKDAY              = time.Monday
+	TALLY_REFRESH_INTERVAL     = 15 * time.Minute
 )
 
 type Proposal struct {
@@ -33,263 +97,416 @@ type Proposal struct {
 	Id       uint64 `json:"id"`
 	Summary  string `json:"summary"`
 	Proposer uint64 `json:"proposer"`
+	Status   string `json:"status,omitempty"`
+	Deadline int64  `json:"deadline,omitempty"`
+	// Unix timestamp of the creation of the proposal, learned from the governance API.
+	Created int64 `json:"created,omitempty"`
+	// Details learned from the governance API while tracking the proposal.
+	Action  string         `json:"action,omitempty"`
+	Tally   *tally         `json:"tally,omitempty"`
+	History []StatusChange `json:"history,omitempty"`
+	// Indented payload of ExecuteNnsFunction proposals and the Telegraph page showing it if it's long.
+	Payload    string `json:"payload,omitempty"`
+	PayloadURL string `json:"payload_url,omitempty"`
+	// Root canister id and name of the SNS the proposal belongs to; empty for NNS proposals.
+	Source     string `json:"source,omitempty"`
+	SourceName string `json:"source_name,omitempty"`
 }
 
-type State struct {
-	LastSeenProposal uint64                    `json:"last_seen_proposal"`
-	ChatIds          map[int64]map[string]bool `json:"chat_ids"`
-	lock             sync.RWMutex
+// Status of a proposal observed since the given time.
+type StatusChange struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
 }
 
-// Locks the state, persists it to a temporary file, then moves the temporary
-// file to the location of the persisted state. This should avoid broken state
-// if the process gets killed in the middle of writing.
-func (s *State) persist() {
-	s.lock.RLock()
-	data, err := json.Marshal(s)
-	s.lock.RUnlock()
-	if err != nil {
-		log.Println("Couldn't serialize state:", err)
+func main() {
+	configure()
+	if flag.Arg(0) == "import" {
+		if flag.NArg() != 2 {
+			log.Fatalln("Usage: nns-proposals-bot import <file.csv|file.json>")
+		}
+		runImport(flag.Arg(1))
 		return
 	}
-	tmpFile, err := ioutil.TempFile(".", STATE_PATH+"_tmp_")
-	if err != nil {
-		log.Fatal(err)
+	if flag.Arg(0) == "export" {
+		runExport(flag.Args()[1:])
+		return
 	}
-	err = os.WriteFile(tmpFile.Name(), data, 0644)
-	if err != nil {
-		log.Println("Couldn't write to state file", STATE_PATH, " :", err)
+	if flag.Arg(0) == "restore-backup" {
+		runRestoreBackup()
+		return
 	}
-	os.Rename(tmpFile.Name(), STATE_PATH)
-	log.Println(len(data), "bytes persisted to", STATE_PATH)
-}
+	loadScoringRules()
 
-// Deserialize the persisted state from the disk. Currently, prints an error on a first run.
-func (s *State) restore() {
-	data, err := os.ReadFile(STATE_PATH)
+	shards, err := newShards(getEnv("TOKENS", os.Getenv("TOKEN")))
 	if err != nil {
-		log.Println("Couldn't read file", STATE_PATH)
+		log.Panic("Couldn't instantiate the bot API:", err)
 	}
-	if err := json.Unmarshal(data, &s); err != nil {
-		log.Println("Couldn't deserialize the state file", STATE_PATH, ":", err)
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	// Reactions are only delivered if requested explicitly.
+	u.AllowedUpdates = []string{"message", "channel_post", "callback_query", "message_reaction"}
+
+	var state State
+	state.restore()
+	shards.state = &state
+	downtime := state.downtime()
+
+	go announceRestart(shards, &state, downtime)
+	queue := newQueue()
+	subscribeConsumers(shards, &state, queue)
+	for i := 0; i < DELIVERY_WORKERS; i++ {
+		go queue.run(shards, &state)
 	}
-	if s.ChatIds == nil {
-		s.ChatIds = map[int64]map[string]bool{}
+	go refreshKnownNeurons()
+	go fetchProposalsAndNotify(shards, &state, queue)
+	go probeFreshness()
+	go persist(&state)
+	go persistOnShutdown(&state)
+	go trackProposals(shards, &state)
+	go flushDeferred(shards, &state)
+	go releaseHeldProposals(&state)
+	go fetchSubscribedProposalsAndNotify(&state)
+	go probeMutedChats(shards, &state)
+	go retryUnreachableChats(shards, &state)
+	if HTTP_ADDR != "" {
+		go serveHTTP(&state)
+	}
+	scheduleJobs(shards, &state)
+	go scheduler.start(&state)
+
+	for u := range shards.updates(u, &state) {
+		handleUpdate(u.bot, shards, &state, queue, u.update)
+		// The offset is persisted with the effects of the update, so after a restart every
+		// update is handled exactly once.
+		state.setUpdateOffset(u.bot.Self.UserName, u.update.UpdateID+1)
 	}
-	fmt.Println("Deserialized the state with", len(s.ChatIds), "users, last proposal id:", s.LastSeenProposal)
 }
 
-// This is an atomic compare and swap for a new seen proposal id.
-func (s *State) setNewLastSeenId(id uint64) (updated bool) {
-	s.lock.Lock()
-	if s.LastSeenProposal < id {
-		s.LastSeenProposal = id
-		updated = true
-	}
-	s.lock.Unlock()
-	return
+// Handles a command, button, callback query or reaction.
+func handleUpdate(bot *tgbotapi.BotAPI, shards *Shards, state *State, queue *Queue, update Update) {
+	if MIRROR_MODE && !fromAdminChat(update) {
+		return
+	}
+	if update.MessageReaction != nil {
+		handleReaction(bot, state, update.MessageReaction)
+		return
+	}
+	if update.CallbackQuery != nil {
+		if message := update.CallbackQuery.Message; message != nil && message.Chat.IsPrivate() {
+			state.setChatBot(message.Chat.ID, bot.Self.UserName)
+		}
+		handleCallback(bot, shards, state, update.CallbackQuery)
+		return
+	}
+	// Commands in channels arrive as channel posts.
+	message := update.Message
+	if message == nil {
+		message = update.ChannelPost
+	}
+	if message == nil {
+		return
+	}
+	id := message.Chat.ID
+	// Recorded after handling the update, so the bot of a chat subscribing with /start is known.
+	if message.Chat.IsPrivate() {
+		defer state.setChatBot(id, bot.Self.UserName)
+	}
+	// Links to proposals are previewed in all subscribed chats where the bot sees the conversation.
+	if !message.Chat.IsChannel() && unfurlLinks(shards, state, message) {
+		return
+	}
+	words := strings.Fields(message.Text)
+	if cmd, ok := buttonCommand(message.Text); ok && message.Chat.IsPrivate() {
+		words = strings.Fields(cmd)
+	}
+	if len(words) == 0 {
+		return
+	}
+	cmd, addressed := parseCommand(words[0], bot.Self.UserName)
+	if !addressed {
+		return
+	}
+	// In groups, ignore the conversation and only react to commands.
+	isGroup := message.Chat.IsGroup() || message.Chat.IsSuperGroup()
+	if isGroup && !strings.HasPrefix(cmd, "/") {
+		return
+	}
+	var msg string
+	if command := findCommand(cmd); command != nil {
+		state.countCommand(id, command.Name)
+		msg = command.Handler(&Request{bot, shards, state, queue, message, id, words[1:], isGroup})
+	} else if !isGroup && state.allowHelp(id, time.Now()) {
+		// Groups may have other bots, so unknown commands are only answered in other chats.
+		msg = getHelpMessage(language(state, id))
+	}
+	if msg != "" {
+		// Replies composed of several messages are translated by their handlers.
+		bot.Send(tgbotapi.NewMessage(id, translate(language(state, id), msg)))
+	}
+	refreshPinnedSettings(bot, state, id)
 }
 
-// Unsubscribes the chat id.
-func (s *State) removeChatId(id int64) {
-	s.lock.Lock()
-	delete(s.ChatIds, id)
-	s.lock.Unlock()
-	log.Println("Removed user", id, "from subscribers")
+// Returns true if `update` originates from the admin chat, the only chat served in mirror mode.
+func fromAdminChat(update Update) bool {
+	var chat *tgbotapi.Chat
+	switch {
+	case update.Message != nil:
+		chat = update.Message.Chat
+	case update.ChannelPost != nil:
+		chat = update.ChannelPost.Chat
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		chat = update.CallbackQuery.Message.Chat
+	case update.MessageReaction != nil:
+		chat = &update.MessageReaction.Chat
+	}
+	return ADMIN_CHAT_ID != 0 && chat != nil && chat.ID == ADMIN_CHAT_ID
 }
 
-// Subscribes the chat id.
-func (s *State) addChatId(id int64) {
-	s.lock.Lock()
-	s.ChatIds[id] = map[string]bool{}
-	s.lock.Unlock()
-	log.Println("Added user", id, "to subscribers")
+// Returns true if the sender of `message` administers the chat. Private chats are administered
+// by the user and channel posts can only be sent by admins.
+func isAdmin(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	return isChatAdmin(bot, message.Chat, message.From)
 }
 
-// Block `topic` for chat `id`. Checks max topic length and max blocked topics to avoid
-// trivial bloat attacks.
-func (s *State) blockTopic(id int64, topic string) {
-	if len(topic) > MAX_TOPIC_LENGTH {
-		return
+// Returns true if `user` administers `chat`. Private chats are administered by the user and in
+// channels, only admins can interact with the bot.
+func isChatAdmin(bot *tgbotapi.BotAPI, chat *tgbotapi.Chat, user *tgbotapi.User) bool {
+	if chat.IsPrivate() || chat.IsChannel() {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	member, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: user.ID},
+	})
+	if err != nil {
+		log.Println("Couldn't fetch the chat member", user.ID, "of", chat.ID, ":", err)
+		return false
 	}
-	s.lock.Lock()
-	blacklist := s.ChatIds[id]
-	if blacklist != nil && len(blacklist) < MAX_BLOCKED_TOPICS {
-		blacklist[topic] = true
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// Returns the value of the environment variable `key` or `fallback` if it's not set.
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
 	}
-	s.lock.Unlock()
+	return fallback
 }
 
-// Unblocks `topic` for chat `id`.
-func (s *State) unblockTopic(id int64, topic string) {
-	s.lock.Lock()
-	blacklist := s.ChatIds[id]
-	if blacklist != nil {
-		delete(blacklist, topic)
-	}
-	s.lock.Unlock()
+// Returns the value of the environment variable `key` as an integer or 0 if it's not set.
+func getEnvInt(key string) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	res, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalln("Couldn't parse", key, ":", err)
+	}
+	return res
 }
 
-// Returns the list of chat ids which should be notified about `topic`.
-func (s *State) chatIdsForTopic(topic string) (res []int64) {
-	s.lock.RLock()
-	for id, blacklist := range s.ChatIds {
-		// Skip if no blacklist or topic is blacklisted.
-		if blacklist == nil || blacklist[topic] {
+// Returns the comma-separated integers in the environment variable `key`.
+func getEnvInts(key string) (res []int64) {
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value == "" {
 			continue
 		}
-		// Skip if only governance topic is whitelisted and the topic is not governance.
-		if blacklist[ALL_EXCEPT_GOVERNANCE] && topic != TOPIC_GOVERNANCE {
-			continue
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Fatalln("Couldn't parse", key, ":", err)
 		}
 		res = append(res, id)
 	}
-	s.lock.RUnlock()
 	return
 }
 
-// Returns a string of blocked topics.
-func (s *State) blockedTopics(id int64) string {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	m := s.ChatIds[id]
-	if m == nil || len(m) == 0 {
-		return "Your list of blocked topics is empty."
-	}
-	var res []string
-	for topic, enabled := range m {
-		if enabled {
-			res = append(res, topic)
-		}
+// Strips the bot name from commands addressed to a specific bot, like `/start@NNSProposalsBot`.
+// Returns false if the command is addressed to another bot.
+func parseCommand(word, botName string) (cmd string, addressed bool) {
+	i := strings.Index(word, "@")
+	if !strings.HasPrefix(word, "/") || i < 0 {
+		return word, true
 	}
-	return fmt.Sprintf("You've blocked these topics: %s.", strings.Join(res, ", "))
+	return word[:i], strings.EqualFold(word[i+1:], botName)
 }
 
-func main() {
-	bot, err := tgbotapi.NewBotAPI(os.Getenv("TOKEN"))
-	if err != nil {
-		log.Panic("Couldn't instantiate the bot API:", err)
+func persist(state *State) {
+	ticker := time.NewTicker(STATE_PERSISTENCE_INTERVAL)
+	for range ticker.C {
+		state.persist()
 	}
+}
 
-	log.Printf("Authorized on account %s", bot.Self.UserName)
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	var state State
-	state.restore()
+// Persists the state and exits on SIGINT or SIGTERM, so that the updates handled since the last
+// persistence aren't handled again after the restart.
+func persistOnShutdown(state *State) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	log.Println("Received", sig, ", persisting the state before exiting")
+	state.persist()
+	os.Exit(0)
+}
 
-	go fetchProposalsAndNotify(bot, &state)
-	go persist(&state)
+// Fetches the most recent proposals from the proposal feed, sorted by id.
+func fetchProposals() (proposals []Proposal, err error) {
+	defer func(start time.Time) { metrics.observeRequest(SOURCE_FEED, start, err) }(time.Now())
+	resp, err := http.Get(URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &proposals); err != nil {
+		return nil, err
+	}
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].Id < proposals[j].Id })
+	if len(proposals) > 0 {
+		metrics.observeNewest(SOURCE_FEED, proposals[len(proposals)-1].Id)
+	}
+	return proposals, nil
+}
 
-	updates := bot.GetUpdatesChan(u)
-	for update := range updates {
-		if update.Message == nil {
+func fetchProposalsAndNotify(shards *Shards, state *State, queue *Queue) {
+	// The feed only contains the most recent proposals, so after a long outage the missed ones
+	// are fetched one by one from the governance API.
+	if offline := state.offline(); offline > DEEP_BACKFILL_THRESHOLD {
+		log.Println("The last successful fetch was", offline, "ago, backfilling from the governance API")
+		backfill(shards, state, queue)
+	}
+	ticker := time.NewTicker(NNS_POLL_INTERVALL)
+	for range ticker.C {
+		// Don't discover new proposals while Telegram can't keep up with the deliveries.
+		if queue.congested() {
+			log.Println("Pausing the discovery of new proposals,", queue.length(), "messages are queued")
 			continue
 		}
-		var msg string
-		id := update.Message.Chat.ID
-		words := strings.Split(update.Message.Text, " ")
-		if len(words) == 0 {
+		proposals, err := NNS.ListProposals()
+		if err != nil {
+			log.Println("Couldn't fetch the proposals from", URL, ":", err)
 			continue
 		}
-		cmd := words[0]
-		switch cmd {
-		case "/start":
-			state.addChatId(id)
-			msg = "Subscribed." + "\n\n" + getHelpMessage()
-		case "/stop":
-			state.removeChatId(id)
-			msg = "Unsubscribed."
-		case "/block", "/unblock":
-			if len(words) != 2 {
-				msg = fmt.Sprintf("Please specify one topic")
-				break
-			}
-			topic := words[1]
-			switch cmd {
-			case "/block":
-				state.blockTopic(id, topic)
-			default:
-				state.unblockTopic(id, topic)
-			}
-			msg = state.blockedTopics(id)
-		case "/governance_only":
-			state.blockTopic(id, ALL_EXCEPT_GOVERNANCE)
-			msg = "From now on, you'll only see the governance proposals."
-		case "/blacklist":
-			msg = state.blockedTopics(id)
-		default:
-			msg = getHelpMessage()
+		state.setLastFetch(time.Now())
+		for _, proposal := range proposals {
+			announce(shards, state, queue, proposal)
 		}
-		bot.Send(tgbotapi.NewMessage(id, msg))
+		go sendHeartbeat()
 	}
 }
 
-func getHelpMessage() string {
-	return "Enter /stop to unsubscribe (/start to resubscribe). " +
-		"Use /block or /unblock to block or unblock proposals with a certain a topic; " +
-		"use /blacklist to display the list of blocked topics. " +
-		"Use /governance_only command to only receive governance proposals."
+// Fetches the proposals published since the last seen one from the governance API and announces
+// them, up to MAX_BACKFILL_PROPOSALS.
+func backfill(shards *Shards, state *State, queue *Queue) {
+	newest, err := fetchNewestProposalId()
+	if err != nil {
+		log.Println("Couldn't fetch the newest proposal for the backfill:", err)
+		return
+	}
+	from := state.lastSeenProposal() + 1
+	if newest >= from+MAX_BACKFILL_PROPOSALS {
+		log.Println("Skipping", newest-from-MAX_BACKFILL_PROPOSALS+1, "proposals in the backfill")
+		from = newest - MAX_BACKFILL_PROPOSALS + 1
+	}
+	for id := from; id <= newest; id++ {
+		proposal, err := NNS.GetProposal(id)
+		if err != nil {
+			log.Println("Couldn't fetch proposal", id, "for the backfill:", err)
+			continue
+		}
+		announce(shards, state, queue, proposal)
+	}
+	state.setLastFetch(time.Now())
 }
 
-func persist(state *State) {
-	ticker := time.NewTicker(STATE_PERSISTENCE_INTERVAL)
-	for range ticker.C {
-		state.persist()
+// Notifies all chats accepting `proposal`, unless it was already seen. Proposals of delayed topics are held back.
+func announce(shards *Shards, state *State, queue *Queue, proposal Proposal) {
+	if proposal.Id <= state.lastSeenProposal() {
+		return
 	}
+	log.Println("New proposal detected:", proposal)
+	holdOrPublish(state, proposal)
+	// Only advanced after the fan-out, so the proposal is announced again if the bot is restarted
+	// in between; chats which already got it within DEDUP_WINDOW are skipped then.
+	state.setNewLastSeenId(proposal.Id)
 }
 
-func fetchProposalsAndNotify(bot *tgbotapi.BotAPI, state *State) {
-	ticker := time.NewTicker(NNS_POLL_INTERVALL)
-	for range ticker.C {
-		resp, err := http.Get(URL)
-		if err != nil {
-			log.Println("GET request failed from", URL, ":", err)
+// Publishes the new `proposal` as discovered, unless its topic is delayed; then it's held back.
+func holdOrPublish(state *State, proposal Proposal) {
+	if delay := TOPIC_DELAYS[proposal.Topic]; delay > 0 {
+		log.Println("Holding proposal", proposal.Id, "back for", delay)
+		state.hold(proposal, time.Now().Add(delay))
+		return
+	}
+	publishDiscovered(state, proposal)
+}
+
+// Queues the notifications about `proposal` for all interested chats.
+func fanOut(shards *Shards, state *State, queue *Queue, proposal Proposal) {
+	// In mirror mode, the bot only posts to the mirror channels.
+	var ids []int64
+	switch {
+	case MIRROR_MODE:
+	case proposal.Source != "":
+		ids = state.chatIdsForSourceProposal(proposal)
+	default:
+		ids = state.chatIdsForProposal(proposal)
+	}
+	for _, id := range ids {
+		chat, ok := state.chat(id)
+		if !ok {
+			continue
 		}
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Println("Couldn't read the response body:", err)
+		if !state.shouldNotify(id, proposal) {
+			log.Println("Suppressed a duplicate notification about", proposal.Id, "to", id)
+			continue
 		}
-
-		var proposals []Proposal
-		if err := json.Unmarshal(body, &proposals); err != nil {
-			fmt.Println("Couldn't parse the response as JSON:", err)
+		if chat.DeliveryMode == DELIVERY_DAILY {
+			state.bufferForDigest(id, proposal)
 			continue
 		}
-
-		sort.Slice(proposals, func(i, j int) bool { return proposals[i].Id < proposals[j].Id })
-
-		for _, proposal := range proposals {
-			if !state.setNewLastSeenId(proposal.Id) {
-				continue
-			}
-			log.Println("New proposal detected:", proposal)
-			summary := proposal.Summary
-			if len(summary)+2 > MAX_SUMMARY_LENGTH {
-				summary = "[Proposal summary is too long.]"
-			}
-			if len(summary) > 0 {
-				summary = "\n" + summary + "\n"
-			}
-			text := fmt.Sprintf("<b>%s</b>\n\nProposer: %d\n%s\n#%s\n\nhttps://nns.ic0.app/proposal/?proposal=%d",
-				proposal.Title, proposal.Proposer, summary, proposal.Topic, proposal.Id)
-
-			ids := state.chatIdsForTopic(proposal.Topic)
-			for _, id := range ids {
-				msg := tgbotapi.NewMessage(id, text)
-				msg.ParseMode = tgbotapi.ModeHTML
-				msg.DisableWebPagePreview = true
-				_, err := bot.Send(msg)
-				if err != nil {
-					log.Println("Couldn't send message:", err)
-					if strings.Contains(err.Error(), "bot was blocked by the user") {
-						state.removeChatId(id)
-					}
-				}
-			}
-			if len(ids) > 0 {
-				log.Println("Successfully notified", len(ids), "users")
-			}
+		if !chat.deliverable(time.Now()) {
+			state.deferProposal(id, proposal)
+			state.recordDeferral(id, proposal)
+			continue
+		}
+		if cooldown := TOPIC_COOLDOWNS[proposal.Topic]; cooldown > 0 && collapsible(proposal) && state.collapse(id, proposal, cooldown) {
+			continue
 		}
+		msg := tgbotapi.NewMessage(id, renderProposal(proposal, chat))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		if markup, ok := notificationKeyboard(state, proposal, chat); ok {
+			msg.ReplyMarkup = markup
+		}
+		queue.push(msg, proposal.Source, proposal.Id, shouldPin(chat, proposal))
+	}
+	if len(ids) > 0 {
+		log.Println("Queued the notifications for", len(ids), "users")
+	}
+	// The mirror and archive channels only relay the NNS.
+	if proposal.Source != "" {
+		return
+	}
+	channels := append([]int64{}, MIRROR_CHANNEL_IDS...)
+	if ARCHIVE_CHANNEL_ID != 0 {
+		channels = append(channels, ARCHIVE_CHANNEL_ID)
+	}
+	for _, channel := range channels {
+		// These channels get every proposal in the default format, independent of any chat settings.
+		msg := tgbotapi.NewMessage(channel, renderProposal(proposal, Chat{}))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		queue.push(msg, "", 0, false)
+	}
+	if VERIFY_ARTIFACTS {
+		go verifyAndAnnotate(shards, state, queue, proposal)
 	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownFencePattern  = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownHeaderPattern = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	markdownListPattern   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	markdownQuotePattern  = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	markdownRulePattern   = regexp.MustCompile(`^\s*((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	markdownInlinePattern = regexp.MustCompile("`([^`\\n]+)`" +
+		`|\[([^\]\n]+)\]\(([^)\s]+)\)` +
+		`|\*\*([^*\n]+)\*\*|__([^_\n]+)__` +
+		`|~~([^~\n]+)~~` +
+		`|\*([^*\s][^*\n]*)\*|\b_([^_\n]+)_\b` +
+		`|</?[a-zA-Z][^>\n]*>`)
+)
+
+// Converts a markdown summary to the HTML subset supported by Telegram: headers become bold,
+// emphasis, strikethrough, inline code, code blocks and http(s) links are kept, list markers
+// become bullets and other constructs like raw HTML tags, rules and quote markers are stripped.
+// Occurrences of `keywords` outside of code are highlighted.
+func markdownToHTML(markdown string, keywords []string) string {
+	var lines []string
+	var code []string
+	inCode := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if markdownFencePattern.MatchString(line) {
+			if inCode {
+				lines = append(lines, "<pre>"+html.EscapeString(strings.Join(code, "\n"))+"</pre>")
+				code = nil
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+		if m := markdownHeaderPattern.FindStringSubmatch(line); m != nil {
+			lines = append(lines, "<b>"+renderInline(m[1], keywords)+"</b>")
+			continue
+		}
+		if markdownRulePattern.MatchString(line) {
+			continue
+		}
+		if m := markdownQuotePattern.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		if m := markdownListPattern.FindStringSubmatch(line); m != nil {
+			lines = append(lines, m[1]+"• "+renderInline(m[2], keywords))
+			continue
+		}
+		lines = append(lines, renderInline(line, keywords))
+	}
+	// A summary truncated within a code block.
+	if inCode {
+		lines = append(lines, "<pre>"+html.EscapeString(strings.Join(code, "\n"))+"</pre>")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Renders the inline markdown of a single line.
+func renderInline(text string, keywords []string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range markdownInlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(highlight(text[last:m[0]], keywords))
+		last = m[1]
+		group := func(i int) (string, bool) {
+			if m[2*i] < 0 {
+				return "", false
+			}
+			return text[m[2*i]:m[2*i+1]], true
+		}
+		if s, ok := group(1); ok {
+			b.WriteString("<code>" + html.EscapeString(s) + "</code>")
+		} else if s, ok := group(2); ok {
+			url, _ := group(3)
+			if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+				b.WriteString(`<a href="` + html.EscapeString(url) + `">` + renderInline(s, keywords) + "</a>")
+			} else {
+				b.WriteString(renderInline(s, keywords))
+			}
+		} else if s, ok := group(4); ok {
+			b.WriteString("<b>" + renderInline(s, keywords) + "</b>")
+		} else if s, ok := group(5); ok {
+			b.WriteString("<b>" + renderInline(s, keywords) + "</b>")
+		} else if s, ok := group(6); ok {
+			b.WriteString("<s>" + renderInline(s, keywords) + "</s>")
+		} else if s, ok := group(7); ok {
+			b.WriteString("<i>" + renderInline(s, keywords) + "</i>")
+		} else if s, ok := group(8); ok {
+			b.WriteString("<i>" + renderInline(s, keywords) + "</i>")
+		}
+		// Raw HTML tags are dropped.
+	}
+	b.WriteString(highlight(text[last:], keywords))
+	return b.String()
+}
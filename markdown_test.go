@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		keywords []string
+		want     string
+	}{
+		{"plain text is escaped", "a < b & c", nil, "a &lt; b &amp; c"},
+		{"header", "## Motivation ##", nil, "<b>Motivation</b>"},
+		{"emphasis", "**bold**, __bold__, *italic*, _italic_ and ~~gone~~", nil,
+			"<b>bold</b>, <b>bold</b>, <i>italic</i>, <i>italic</i> and <s>gone</s>"},
+		{"inline code is escaped", "run `a <b>`", nil, "run <code>a &lt;b&gt;</code>"},
+		{"http link", "[forum](https://forum.dfinity.org/t/1)", nil, `<a href="https://forum.dfinity.org/t/1">forum</a>`},
+		{"other links lose their URL", "[file](file:///etc/passwd)", nil, "file"},
+		{"list", "- one\n  * two", nil, "• one\n  • two"},
+		{"quote", "> quoted", nil, "quoted"},
+		{"rule", "a\n---\nb", nil, "a\nb"},
+		{"raw html is dropped", "<div>text</div>", nil, "text"},
+		{"code block", "```\nif a < b {\n```", nil, "<pre>if a &lt; b {</pre>"},
+		{"truncated code block", "```\nfn main()", nil, "<pre>fn main()</pre>"},
+		{"no emphasis in words", "snake_case_name", nil, "snake_case_name"},
+		{"highlighted keywords", "Upgrade the **registry** of subnet a&b", []string{"subnet", "b"},
+			"Upgrade the <b>registry</b> of <b>subnet</b> a&amp;<b>b</b>"},
+		{"highlights in link texts but not in code", "[subnet](https://example.com/subnet) and `subnet`", []string{"subnet"},
+			`<a href="https://example.com/subnet"><b>subnet</b></a> and <code>subnet</code>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownToHTML(tt.markdown, tt.keywords); got != tt.want {
+				t.Errorf("markdownToHTML(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRenderedMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+	}{
+		{"code block", "## Motivation\n\nThe upgrade fixes a bug.\n\n```\n" + strings.Repeat("cargo build --release && shasum -a 256 <file>\n", 30) + "```"},
+		{"list with links", strings.Repeat("- **Fix** the [release notes](https://example.com/notes?a=1&b=2) of `ic-os`\n", 30)},
+		{"long paragraph", strings.Repeat("This proposal upgrades the _governance_ canister to the ~~old~~ new version. ", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(markdownToHTML(tt.markdown, nil), 500, true)
+			if len(parts) < 2 {
+				t.Fatalf("splitMessage() returned %d part, expected the text to be split", len(parts))
+			}
+			checkHTMLParts(t, parts, 500)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	// Whether the limits default to values suitable for hosts with 128 to 256 MB of memory.
+	LOW_MEMORY       = false
+	DELIVERY_WORKERS = 1
+)
+
+// Lowered defaults of the caches and the queue in low memory mode.
+var lowMemoryDefaults = map[string]string{
+	"archive-size":     "200",
+	"queue-length":     "1000",
+	"queue-pause":      "100",
+	"delivery-records": "20",
+	"tldr-cache-size":  "50",
+}
+
+// Lowers the defaults of the caches and the queue in low memory mode, except for the `explicit`
+// flags. Runs before the environment variables and the configuration file are applied, so they
+// still take precedence.
+func applyLowMemoryDefaults(explicit map[string]bool) {
+	if !LOW_MEMORY {
+		return
+	}
+	for name, value := range lowMemoryDefaults {
+		if !explicit[name] {
+			flag.Set(name, value)
+		}
+	}
+	// Trade CPU for a smaller heap.
+	debug.SetGCPercent(50)
+}
+
+func (s *State) cachedProposals() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.Recent)
+}
+
+// Returns the memory usage and the fill levels of the bounded caches and the queue for /status.
+func memoryStatus(state *State, queue *Queue) string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return fmt.Sprintf("Memory: %d MB heap, %d MB from the OS, %d goroutines\n"+
+		"Limits: %d of %d cached proposals, %d of %d queued messages (the discovery pauses at %d), %d delivery workers",
+		m.HeapAlloc>>20, m.Sys>>20, runtime.NumGoroutine(), state.cachedProposals(), MAX_CACHED_PROPOSALS,
+		queue.length(), MAX_QUEUE_LENGTH, QUEUE_PAUSE_THRESHOLD, DELIVERY_WORKERS)
+}
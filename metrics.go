@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the proposal sources.
+const (
+	SOURCE_FEED           = "feed"
+	SOURCE_GOVERNANCE_API = "governance_api"
+	SOURCE_SNS_API        = "sns_api"
+)
+
+// Request and freshness metrics of a proposal source.
+type SourceMetrics struct {
+	Requests     int
+	Errors       int
+	TotalLatency time.Duration
+	LastLatency  time.Duration
+	// Newest proposal returned by the source and when it was first returned.
+	NewestId    uint64
+	NewestSince time.Time
+}
+
+// Metrics of all proposal sources and of the deliveries. The feed is served by a proxy canister, so it can lag behind
+// the governance API, which reflects the NNS directly.
+type Metrics struct {
+	sources map[string]*SourceMetrics
+	// Time since which the feed is missing the newest proposal of the governance API.
+	behindSince time.Time
+	// Failed delivery attempts by error class.
+	retries map[errorClass]int
+	// Sent messages and messages which couldn't be sent since the start of the bot.
+	sent, failed int
+	started      time.Time
+	// Discovered proposals by topic and observed status changes by the new status.
+	discovered    map[string]int
+	statusChanges map[string]int
+	// Notification latencies by stage and the recent discoveries they are measured from.
+	latencies   map[string]*latencyMetrics
+	discoveries map[uint64]discovery
+	lock        sync.Mutex
+}
+
+var metrics = Metrics{sources: map[string]*SourceMetrics{}, retries: map[errorClass]int{}, started: time.Now(),
+	discovered: map[string]int{}, statusChanges: map[string]int{},
+	latencies: map[string]*latencyMetrics{}, discoveries: map[uint64]discovery{}}
+
+func (m *Metrics) source(name string) *SourceMetrics {
+	if m.sources[name] == nil {
+		m.sources[name] = &SourceMetrics{}
+	}
+	return m.sources[name]
+}
+
+// Records a request to `source` which started at `start`.
+func (m *Metrics) observeRequest(source string, start time.Time, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	s := m.source(source)
+	s.Requests++
+	if err != nil {
+		s.Errors++
+	}
+	s.LastLatency = time.Since(start)
+	s.TotalLatency += s.LastLatency
+}
+
+// Records the id of the newest proposal returned by `source`.
+func (m *Metrics) observeNewest(source string, id uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	s := m.source(source)
+	if id > s.NewestId {
+		s.NewestId, s.NewestSince = id, time.Now()
+	}
+	feed, api := m.source(SOURCE_FEED).NewestId, m.source(SOURCE_GOVERNANCE_API).NewestId
+	switch {
+	case feed == 0 || feed >= api:
+		m.behindSince = time.Time{}
+	case m.behindSince.IsZero():
+		m.behindSince = time.Now()
+	}
+}
+
+// Records a failed delivery attempt.
+func (m *Metrics) observeRetry(class errorClass) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.retries[class]++
+}
+
+// Records the outcome of sending a message.
+func (m *Metrics) observeSend(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err != nil {
+		m.failed++
+	} else {
+		m.sent++
+	}
+}
+
+// Returns the number of sent and failed messages and since when they are counted.
+func (m *Metrics) sends() (sent, failed int, since time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.sent, m.failed, m.started
+}
+
+func (m *Metrics) observeDiscovered(topic string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.discovered[topic]++
+}
+
+func (m *Metrics) observeStatusChange(status string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.statusChanges[status]++
+}
+
+// Returns a copy of the failed delivery attempts by error class.
+func (m *Metrics) deliveryRetries() map[errorClass]int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	res := map[errorClass]int{}
+	for class, count := range m.retries {
+		res[class] = count
+	}
+	return res
+}
+
+// Returns the number of proposals the feed is behind the governance API and for how long.
+// Expects the lock to be held.
+func (m *Metrics) lag() (uint64, time.Duration) {
+	if m.behindSince.IsZero() {
+		return 0, 0
+	}
+	return m.source(SOURCE_GOVERNANCE_API).NewestId - m.source(SOURCE_FEED).NewestId, time.Since(m.behindSince)
+}
+
+func (m *Metrics) sortedSources() (res []string) {
+	for name := range m.sources {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return
+}
+
+func sortedCounts(values map[string]int) (res []string) {
+	for key := range values {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return
+}
+
+// Returns a human-readable summary of the metrics for /status.
+func (m *Metrics) status() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.sources) == 0 {
+		return "No requests to the proposal sources yet."
+	}
+	var lines []string
+	for _, name := range m.sortedSources() {
+		s := m.sources[name]
+		line := fmt.Sprintf("%s: %d requests, %d errors (%.1f%%), latency %s (average %s)", name, s.Requests, s.Errors,
+			100*float64(s.Errors)/float64(s.Requests), s.LastLatency.Round(time.Millisecond),
+			(s.TotalLatency / time.Duration(s.Requests)).Round(time.Millisecond))
+		if s.NewestId > 0 {
+			line += fmt.Sprintf(", newest proposal %d since %s", s.NewestId, formatCountdown(time.Since(s.NewestSince)))
+		}
+		lines = append(lines, line)
+	}
+	switch behind, since := m.lag(); {
+	case behind == 0:
+		lines = append(lines, "The feed is up to date.")
+	case since > FRESHNESS_SLO:
+		lines = append(lines, fmt.Sprintf("⚠️ The feed is %d proposals behind the governance API since %s (SLO: %s).",
+			behind, formatCountdown(since), FRESHNESS_SLO))
+	default:
+		lines = append(lines, fmt.Sprintf("The feed is %d proposals behind the governance API since %s.", behind, formatCountdown(since)))
+	}
+	lines = append(lines, m.latencyStatus()...)
+	return strings.Join(lines, "\n")
+}
+
+// Writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) writePrometheus(w io.Writer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	series := func(name, kind, help string, value func(s *SourceMetrics) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, source := range m.sortedSources() {
+			fmt.Fprintf(w, "%s{source=%q} %g\n", name, source, value(m.sources[source]))
+		}
+	}
+	series("nns_source_requests_total", "counter", "Requests to the proposal source.",
+		func(s *SourceMetrics) float64 { return float64(s.Requests) })
+	series("nns_source_errors_total", "counter", "Failed requests to the proposal source.",
+		func(s *SourceMetrics) float64 { return float64(s.Errors) })
+	series("nns_source_latency_seconds_total", "counter", "Total latency of the requests to the proposal source.",
+		func(s *SourceMetrics) float64 { return s.TotalLatency.Seconds() })
+	series("nns_source_newest_proposal_id", "gauge", "Newest proposal returned by the source.",
+		func(s *SourceMetrics) float64 { return float64(s.NewestId) })
+	series("nns_source_newest_proposal_age_seconds", "gauge", "Time since the source first returned its newest proposal.",
+		func(s *SourceMetrics) float64 {
+			if s.NewestSince.IsZero() {
+				return 0
+			}
+			return time.Since(s.NewestSince).Seconds()
+		})
+	counter := func(name, label, help string, values map[string]int) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, key := range sortedCounts(values) {
+			fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, key, values[key])
+		}
+	}
+	fmt.Fprintf(w, "# HELP nns_messages_sent_total Sent messages.\n# TYPE nns_messages_sent_total counter\nnns_messages_sent_total %d\n", m.sent)
+	fmt.Fprintf(w, "# HELP nns_messages_failed_total Messages which couldn't be sent.\n# TYPE nns_messages_failed_total counter\nnns_messages_failed_total %d\n", m.failed)
+	counter("nns_proposals_discovered_total", "topic", "Discovered proposals.", m.discovered)
+	counter("nns_proposal_status_changes_total", "status", "Observed status changes of proposals.", m.statusChanges)
+	m.writeLatencies(w)
+	behind, since := m.lag()
+	fmt.Fprintf(w, "# HELP nns_feed_lag_proposals Proposals the feed is behind the governance API.\n# TYPE nns_feed_lag_proposals gauge\nnns_feed_lag_proposals %d\n", behind)
+	fmt.Fprintf(w, "# HELP nns_feed_lag_seconds Time since the feed is behind the governance API.\n# TYPE nns_feed_lag_seconds gauge\nnns_feed_lag_seconds %g\n", since.Seconds())
+}
+
+// Periodically fetches the newest proposal from the governance API to measure the freshness of
+// the feed.
+func probeFreshness() {
+	ticker := time.NewTicker(NNS_POLL_INTERVALL)
+	for range ticker.C {
+		id, err := fetchNewestProposalId()
+		if err != nil {
+			log.Println("Couldn't fetch the newest proposal from the governance API:", err)
+			continue
+		}
+		metrics.observeNewest(SOURCE_GOVERNANCE_API, id)
+	}
+}
+
+func statusCommand(r *Request) string {
+	return withTestnetBanner(metrics.status() + "\n" + memoryStatus(r.state, r.queue))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var (
+	// Access token of the Telegraph account long payloads are published with; without it, long
+	// payloads are only linked on the dashboard.
+	TELEGRAPH_TOKEN           = os.Getenv("TELEGRAPH_TOKEN")
+	TELEGRAPH_API_URL         = "https://api.telegra.ph"
+	MAX_INLINE_PAYLOAD_LENGTH = 800
+)
+
+// Action of proposals calling a method of an NNS canister, whose payload is worth showing.
+const ACTION_EXECUTE_NNS_FUNCTION = "ExecuteNnsFunction"
+
+// Returns the indented payload of `details` or an empty string if it has none worth showing.
+func formatPayload(details apiProposal) string {
+	if details.Action != ACTION_EXECUTE_NNS_FUNCTION && details.Action != ACTION_INSTALL_CODE ||
+		len(details.Payload) == 0 || string(details.Payload) == "null" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, details.Payload, "", "  "); err != nil {
+		log.Println("Couldn't format the payload of proposal", details.Id, ":", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// Publishes the payload of `proposal` on Telegraph and returns the URL of the page.
+func publishPayload(proposal Proposal) (string, error) {
+	page, err := json.Marshal([]map[string]interface{}{{"tag": "pre", "children": []string{proposal.Payload}}})
+	if err != nil {
+		return "", err
+	}
+	resp, err := apiClient.PostForm(TELEGRAPH_API_URL+"/createPage", url.Values{
+		"access_token": {TELEGRAPH_TOKEN},
+		"title":        {fmt.Sprintf("Payload of proposal %d", proposal.Id)},
+		"content":      {string(page)},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var res struct {
+		Ok     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Result struct {
+			URL string `json:"url"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	if !res.Ok {
+		return "", fmt.Errorf("%s", res.Error)
+	}
+	return res.Result.URL, nil
+}
+
+// Publishes the payload of `proposal` on Telegraph if it's too long to be shown inline.
+func publishLongPayload(proposal *Proposal) {
+	if len(proposal.Payload) <= MAX_INLINE_PAYLOAD_LENGTH || TELEGRAPH_TOKEN == "" || proposal.PayloadURL != "" {
+		return
+	}
+	link, err := publishPayload(*proposal)
+	if err != nil {
+		log.Println("Couldn't publish the payload of proposal", proposal.Id, "on Telegraph:", err)
+		return
+	}
+	proposal.PayloadURL = link
+}
+
+// Renders the key fields of the payload of `proposal` followed by the payload inline, or by a link
+// if it's too long.
+func renderPayload(proposal Proposal) string {
+	fields := renderPayloadFields(proposal)
+	switch {
+	case proposal.Payload == "":
+		return ""
+	case len(proposal.Payload) <= MAX_INLINE_PAYLOAD_LENGTH:
+		return fields + "<pre>" + html.EscapeString(proposal.Payload) + "</pre>\n"
+	case proposal.PayloadURL != "":
+		return fields + fmt.Sprintf("<a href=\"%s\">Show the payload</a>\n", html.EscapeString(proposal.PayloadURL))
+	}
+	return fields + fmt.Sprintf("<a href=\"%s\">The payload is too long to be shown here</a>\n", htmlURL(proposal))
+}
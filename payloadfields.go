@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Action of proposals installing or upgrading a canister controlled by the NNS.
+const ACTION_INSTALL_CODE = "InstallCode"
+
+const (
+	MAX_LISTED_NODES    = 5
+	MAX_PAYLOAD_NESTING = 3
+)
+
+// Fields of the payloads of common proposals which are worth showing above the payload, in the order
+// they are shown.
+var payloadKeyFields = []struct{ key, label string }{
+	{"canister_id", "Target canister"},
+	{"mode", "Install mode"},
+	{"install_mode", "Install mode"},
+	{"wasm_module_hash", "Wasm hash"},
+	{"arg_hash", "Argument hash"},
+	{"subnet_id", "Subnet"},
+	{"replica_version_to_elect", "Elected version"},
+	{"replica_version_id", "Version"},
+	{"release_package_sha256_hex", "Release package hash"},
+	{"node_ids", "Nodes"},
+	{"node_ids_add", "Added nodes"},
+	{"node_ids_remove", "Removed nodes"},
+}
+
+// Returns the value of the first occurrence of `key` in the decoded JSON `value`, searching nested
+// objects up to MAX_PAYLOAD_NESTING levels deep.
+func findPayloadField(value interface{}, key string, depth int) (interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok || depth > MAX_PAYLOAD_NESTING {
+		return nil, false
+	}
+	if v, ok := object[key]; ok && v != nil {
+		return v, true
+	}
+	// Sorted, so that the same field is found on every rendering.
+	var names []string
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := findPayloadField(object[name], key, depth+1); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Formats a payload value in one line: byte arrays like hashes as hex, lists of ids shortened to
+// MAX_LISTED_NODES entries.
+func formatPayloadValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case float64, bool:
+		return fmt.Sprint(v), true
+	case []interface{}:
+		if bytes, ok := payloadBytes(v); ok {
+			return hex.EncodeToString(bytes), true
+		}
+		var items []string
+		for _, item := range v {
+			if s, ok := formatPayloadValue(item); ok {
+				items = append(items, s)
+			}
+		}
+		if len(items) == 0 {
+			return "", false
+		}
+		if len(items) > MAX_LISTED_NODES {
+			return fmt.Sprintf("%s and %d more", strings.Join(items[:MAX_LISTED_NODES], ", "), len(items)-MAX_LISTED_NODES), true
+		}
+		return strings.Join(items, ", "), true
+	}
+	return "", false
+}
+
+// Returns the bytes of a JSON array of numbers between 0 and 255.
+func payloadBytes(values []interface{}) ([]byte, bool) {
+	var res []byte
+	for _, value := range values {
+		n, ok := value.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(int(n)) {
+			return nil, false
+		}
+		res = append(res, byte(n))
+	}
+	return res, len(res) > 0
+}
+
+// Renders the key fields of the payload of `proposal`, e.g. the target canister and the wasm hash
+// of an upgrade or the nodes added to a subnet.
+func renderPayloadFields(proposal Proposal) string {
+	var payload interface{}
+	if proposal.Payload == "" || json.Unmarshal([]byte(proposal.Payload), &payload) != nil {
+		return ""
+	}
+	var lines []string
+	shown := map[string]bool{}
+	for _, field := range payloadKeyFields {
+		value, ok := findPayloadField(payload, field.key, 0)
+		if !ok || shown[field.label] {
+			continue
+		}
+		if s, ok := formatPayloadValue(value); ok {
+			shown[field.label] = true
+			lines = append(lines, fmt.Sprintf("<b>%s:</b> <code>%s</code>", field.label, html.EscapeString(s)))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Topics of proposals considered critical: upgrades of the core canisters and elections of
+// IC OS versions and their deployment to the subnets.
+var CRITICAL_TOPICS = map[string]bool{
+	"NetworkCanisterManagement":  true,
+	"ProtocolCanisterManagement": true,
+	"IcOsVersionDeployment":      true,
+	"IcOsVersionElection":        true,
+}
+
+// Returns true if the notification about `proposal` should be pinned in `chat`.
+func shouldPin(chat Chat, proposal Proposal) bool {
+	return chat.AutoPin && proposal.Source == "" && CRITICAL_TOPICS[proposal.Topic]
+}
+
+// Switches the automatic pinning of critical proposals for chat `id`.
+func (s *State) setAutoPin(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.AutoPin = enabled
+	return true
+}
+
+// Records whether the notification about `proposalId` is pinned in chat `id`.
+func (s *State) setPinned(id int64, proposalId uint64, pinned bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.delivery(proposalId).Pinned = pinned
+	}
+}
+
+// Returns the chats in which the notification about `proposalId` is pinned, mapped to the id of
+// the pinned message.
+func (s *State) pinnedMessages(proposalId uint64) map[int64]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	res := map[int64]int{}
+	for id, chat := range s.ChatIds {
+		for _, d := range chat.Deliveries {
+			if d.of("", proposalId) && d.Pinned {
+				res[id] = d.MessageId
+			}
+		}
+	}
+	return res
+}
+
+// Pins the notification about `proposalId` sent as message `messageId` to chat `id`.
+func pinProposal(shards *Shards, state *State, id int64, proposalId uint64, messageId int) {
+	config := tgbotapi.PinChatMessageConfig{ChatID: id, MessageID: messageId, DisableNotification: true}
+	if _, err := shards.botFor(id).Request(config); err != nil {
+		log.Println("Couldn't pin proposal", proposalId, "in", id, ":", err)
+		return
+	}
+	state.setPinned(id, proposalId, true)
+}
+
+// Unpins the notifications about the decided proposal `proposalId` in all chats.
+func unpinProposal(shards *Shards, state *State, proposalId uint64) {
+	for id, messageId := range state.pinnedMessages(proposalId) {
+		config := tgbotapi.UnpinChatMessageConfig{ChatID: id, MessageID: messageId}
+		if _, err := shards.botFor(id).Request(config); err != nil {
+			log.Println("Couldn't unpin proposal", proposalId, "in", id, ":", err)
+		}
+		state.setPinned(id, proposalId, false)
+	}
+}
+
+func autoPinCommand(r *Request) string {
+	if !r.isGroup && !r.message.Chat.IsChannel() {
+		return "Automatic pinning is only available in channels and groups."
+	}
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !r.state.setAutoPin(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "Critical proposals (core canister upgrades and version elections) will be pinned until they are decided; " +
+			"please make sure the bot is allowed to pin messages."
+	}
+	return "Critical proposals won't be pinned anymore."
+}
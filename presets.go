@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Named bundles of topics a chat can follow with one command.
+var PRESETS = map[string][]string{
+	"node-operator": {"SubnetManagement", "NodeAdmin", "ParticipantManagement", "IcOsVersionElection"},
+	"tokenholder":   {"Governance", "NetworkEconomics", "SnsAndCommunityFund"},
+}
+
+func presetNames() []string {
+	var res []string
+	for name := range PRESETS {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func presetCommand(r *Request) string {
+	if len(r.args) != 1 || PRESETS[r.args[0]] == nil {
+		lines := []string{"Please choose a preset:"}
+		for _, name := range presetNames() {
+			lines = append(lines, fmt.Sprintf("%s: %s", name, strings.Join(PRESETS[name], ", ")))
+		}
+		return strings.Join(lines, "\n")
+	}
+	topics := PRESETS[r.args[0]]
+	if !r.state.setOnlyTopics(r.id, topics) {
+		return NOT_SUBSCRIBED
+	}
+	return fmt.Sprintf("From now on, you'll only see proposals with these topics: %s.", strings.Join(topics, ", "))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the cached proposals submitted by the neuron `proposer`.
+func (s *State) proposalsBy(proposer uint64) (res []Proposal) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, p := range s.Recent {
+		if p.Proposer == proposer {
+			res = append(res, p)
+		}
+	}
+	return
+}
+
+// Renders the statistics about the proposals of `proposer` in the rolling cache.
+func proposerSummary(proposer uint64, name string, proposals []Proposal) string {
+	title := fmt.Sprintf("Neuron %d", proposer)
+	if name != "" {
+		title += " (" + name + ")"
+	}
+	if len(proposals) == 0 {
+		return title + " didn't submit any of the recent proposals."
+	}
+	adopted, decided := 0, 0
+	topics := map[string]int{}
+	for _, p := range proposals {
+		switch p.Status {
+		case STATUS_ADOPTED, STATUS_EXECUTED:
+			adopted++
+			decided++
+		case STATUS_REJECTED, STATUS_FAILED:
+			decided++
+		}
+		topics[p.Topic]++
+	}
+	var ranks []rank
+	for topic, count := range topics {
+		ranks = append(ranks, rank{topic, float64(count)})
+	}
+	var topicLines []string
+	for _, r := range topRanks(ranks) {
+		topicLines = append(topicLines, fmt.Sprintf("#%s: %d", r.name, int(r.value)))
+	}
+	adoption := "no decided proposals yet"
+	if decided > 0 {
+		adoption = fmt.Sprintf("%.0f%% of %d decided proposals", 100*float64(adopted)/float64(decided), decided)
+	}
+	return fmt.Sprintf("%s submitted %d of the last %d proposals (latest: %d).\nAdoption rate: %s\n\nTop topics:\n%s",
+		title, len(proposals), MAX_CACHED_PROPOSALS, proposals[len(proposals)-1].Id, adoption, strings.Join(topicLines, "\n"))
+}
+
+func proposerCommand(r *Request) string {
+	proposer, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a neuron id"
+	}
+	return proposerSummary(proposer, knownNeurons.name(proposer), r.state.proposalsBy(proposer))
+}
+
+// Adds `proposer` to or removes it from `set`, with the same size limit as blocked topics.
+func updateProposers(set *map[uint64]bool, proposer uint64, add bool) error {
+	if !add {
+		delete(*set, proposer)
+		return nil
+	}
+	if len(*set) >= MAX_BLOCKED_TOPICS {
+		return fmt.Errorf("at most %d proposers are allowed", MAX_BLOCKED_TOPICS)
+	}
+	if *set == nil {
+		*set = map[uint64]bool{}
+	}
+	(*set)[proposer] = true
+	return nil
+}
+
+// Adds `proposer` to or removes it from the followed proposers of chat `id`.
+func (s *State) setFollowedProposer(id int64, proposer uint64, followed bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	return updateProposers(&chat.FollowedProposers, proposer, followed)
+}
+
+// Adds `proposer` to or removes it from the blocked proposers of chat `id`.
+func (s *State) setBlockedProposer(id int64, proposer uint64, blocked bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	return updateProposers(&chat.BlockedProposers, proposer, blocked)
+}
+
+func followProposerCommand(r *Request) string {
+	proposer, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a neuron id"
+	}
+	if err := r.state.setFollowedProposer(r.id, proposer, true); err != nil {
+		return "Couldn't follow the proposer: " + err.Error() + "."
+	}
+	return fmt.Sprintf("You'll receive all proposals of neuron %d, regardless of your filters.", proposer)
+}
+
+func unfollowProposerCommand(r *Request) string {
+	proposer, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a neuron id"
+	}
+	r.state.setFollowedProposer(r.id, proposer, false)
+	return fmt.Sprintf("The proposals of neuron %d are filtered like all others again.", proposer)
+}
+
+func blockProposerCommand(r *Request) string {
+	proposer, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a neuron id"
+	}
+	if err := r.state.setBlockedProposer(r.id, proposer, true); err != nil {
+		return "Couldn't block the proposer: " + err.Error() + "."
+	}
+	return fmt.Sprintf("You won't receive the proposals of neuron %d anymore.", proposer)
+}
+
+func unblockProposerCommand(r *Request) string {
+	proposer, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a neuron id"
+	}
+	r.state.setBlockedProposer(r.id, proposer, false)
+	return fmt.Sprintf("The proposals of neuron %d are filtered like all others again.", proposer)
+}
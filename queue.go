@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Notification waiting for delivery. If `proposalId` is set, the result is recorded in the
+// delivery report of the chat and the sent message is pinned if `pin` is set. `source` is the
+// namespace of the governance system of the proposal.
+type job struct {
+	msg        tgbotapi.MessageConfig
+	source     string
+	proposalId uint64
+	pin        bool
+}
+
+// Outbound queue decoupling the discovery of proposals from their delivery. Its capacity bounds
+// the memory used for pending notifications; once the queue is filled beyond
+// QUEUE_PAUSE_THRESHOLD, the discovery of new proposals is paused until it drains.
+type Queue struct {
+	jobs chan job
+	// Times at which the pending jobs were enqueued, oldest first.
+	enqueued []time.Time
+	lock     sync.Mutex
+}
+
+func newQueue() *Queue {
+	return &Queue{jobs: make(chan job, MAX_QUEUE_LENGTH)}
+}
+
+// Enqueues a notification; blocks while the queue is full.
+func (q *Queue) push(msg tgbotapi.MessageConfig, source string, proposalId uint64, pin bool) {
+	q.lock.Lock()
+	q.enqueued = append(q.enqueued, time.Now())
+	q.lock.Unlock()
+	q.jobs <- job{msg, source, proposalId, pin}
+}
+
+// Returns the time the oldest pending job is waiting for.
+func (q *Queue) oldest() time.Duration {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.enqueued) == 0 {
+		return 0
+	}
+	return time.Since(q.enqueued[0])
+}
+
+func (q *Queue) length() int {
+	return len(q.jobs)
+}
+
+// Returns true if the discovery of new proposals should be paused.
+func (q *Queue) congested() bool {
+	return q.length() >= QUEUE_PAUSE_THRESHOLD
+}
+
+// Delivers the queued notifications.
+func (q *Queue) run(shards *Shards, state *State) {
+	congested := false
+	for job := range q.jobs {
+		q.lock.Lock()
+		q.enqueued = q.enqueued[1:]
+		q.lock.Unlock()
+		congested = congested || q.congested()
+		sent, err := send(shards, state, job.msg)
+		if job.proposalId != 0 {
+			state.recordDelivery(job.msg.ChatID, job.source, job.proposalId, sent.MessageID, err)
+			if err == nil {
+				metrics.observeDelivery(job.source, job.proposalId)
+			}
+		}
+		if job.pin && err == nil {
+			pinProposal(shards, state, job.msg.ChatID, job.proposalId, sent.MessageID)
+		}
+		if congested && q.length() == 0 {
+			log.Println("The delivery queue is drained; resuming the discovery of new proposals")
+			congested = false
+		}
+	}
+}
+
+// Returns the number of muted and unreachable chats and of the proposals deferred for them.
+func (s *State) deliveryBacklog() (muted, mutedDeferred, unreachable, unreachableDeferred int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, chat := range s.ChatIds {
+		if chat.MutedSince != nil {
+			muted++
+			mutedDeferred += len(chat.Deferred)
+		}
+		if chat.UnreachableSince != nil {
+			unreachable++
+			unreachableDeferred += len(chat.Deferred)
+		}
+	}
+	return
+}
+
+func queueCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	lines := []string{fmt.Sprintf("Queued messages: %d of at most %d", r.queue.length(), MAX_QUEUE_LENGTH)}
+	if r.queue.length() > 0 {
+		lines[0] += fmt.Sprintf(", the oldest is waiting for %s", r.queue.oldest().Round(time.Second))
+	}
+	if r.queue.congested() {
+		lines = append(lines, "⚠️ The discovery of new proposals is paused until the queue drains.")
+	}
+	var retries []string
+	for class, count := range metrics.deliveryRetries() {
+		retries = append(retries, fmt.Sprintf("%s: %d", class, count))
+	}
+	if len(retries) == 0 {
+		retries = []string{"none"}
+	}
+	sort.Strings(retries)
+	lines = append(lines, "Retries: "+strings.Join(retries, ", "))
+	muted, mutedDeferred, unreachable, unreachableDeferred := r.state.deliveryBacklog()
+	lines = append(lines, fmt.Sprintf("Backlog: %d proposals deferred for %d chats without the permission to post, "+
+		"%d proposals deferred for %d chats which blocked the bot", mutedDeferred, muted, unreachableDeferred, unreachable))
+	return strings.Join(lines, "\n")
+}
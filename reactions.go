@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Change of the reactions of a user to a message. Only delivered in chats where the bot is an
+// admin.
+type MessageReaction struct {
+	Chat        tgbotapi.Chat  `json:"chat"`
+	MessageId   int            `json:"message_id"`
+	User        *tgbotapi.User `json:"user"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+type ReactionType struct {
+	Type  string `json:"type"`
+	Emoji string `json:"emoji"`
+}
+
+const (
+	BLOCK_REACTION       = "👎"
+	CALLBACK_BLOCK       = "block:"
+	CALLBACK_BLOCK_TOPIC = "block_topic:"
+	CALLBACK_SENTIMENT   = "sentiment:"
+	CALLBACK_DISMISS     = "dismiss"
+	// Limit of the Telegram Bot API.
+	MAX_CALLBACK_DATA_LENGTH = 64
+)
+
+// Returns the callback data of a button acting on `topic`, or false if the topic is too long for
+// the callback data.
+func topicCallbackData(prefix, topic string) (string, bool) {
+	data := prefix + topic
+	return data, topic != "" && len(data) <= MAX_CALLBACK_DATA_LENGTH
+}
+
+// Returns the button blocking the topic of `proposal` with one tap. Topics too long for the
+// callback data get no button.
+func blockTopicButton(proposal Proposal) (tgbotapi.InlineKeyboardButton, bool) {
+	data, ok := topicCallbackData(CALLBACK_BLOCK_TOPIC, proposal.Topic)
+	if !ok {
+		return tgbotapi.InlineKeyboardButton{}, false
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("🚫 Block #"+proposal.Topic, data), true
+}
+
+// Returns the proposal announced with message `messageId` in chat `id`.
+func (s *State) proposalForMessage(id int64, messageId int) (uint64, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		for _, d := range chat.Deliveries {
+			if messageId != 0 && d.MessageId == messageId && d.Sent != nil && d.Source == "" {
+				return d.ProposalId, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Counts the reactions to proposal notifications and offers to block the topic of a proposal
+// whose notification got a 👎 reaction.
+func handleReaction(bot *tgbotapi.BotAPI, state *State, reaction *MessageReaction) {
+	id := reaction.Chat.ID
+	proposalId, ok := state.proposalForMessage(id, reaction.MessageId)
+	if !ok || len(reaction.NewReaction) == 0 {
+		return
+	}
+	state.recordReaction(proposalId)
+	thumbsDown := false
+	for _, r := range reaction.NewReaction {
+		thumbsDown = thumbsDown || r.Type == "emoji" && r.Emoji == BLOCK_REACTION
+	}
+	if !thumbsDown {
+		return
+	}
+	proposal, ok := state.cachedProposal(proposalId)
+	if !ok {
+		return
+	}
+	msg := tgbotapi.NewMessage(id, fmt.Sprintf("Want to block #%s?", proposal.Topic))
+	msg.ReplyToMessageID = reaction.MessageId
+	msg.AllowSendingWithoutReply = true
+	if data, ok := topicCallbackData(CALLBACK_BLOCK, proposal.Topic); ok {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Block #"+proposal.Topic, data),
+			tgbotapi.NewInlineKeyboardButtonData("No", CALLBACK_DISMISS),
+		))
+	} else {
+		// Topics too long for the callback data can only be blocked with the command.
+		msg.Text = fmt.Sprintf("Want to block #%s? Use /block %s.", proposal.Topic, proposal.Topic)
+	}
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("Couldn't offer to block", proposal.Topic, "in", id, ":", err)
+	}
+}
+
+// Handles the buttons of the inline keyboards sent by the bot.
+func handleCallback(bot *tgbotapi.BotAPI, shards *Shards, state *State, query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+	id, messageId := query.Message.Chat.ID, query.Message.MessageID
+	answer := ""
+	switch {
+	case query.Data == CALLBACK_DISMISS:
+		bot.Request(tgbotapi.NewDeleteMessage(id, messageId))
+	case strings.HasPrefix(query.Data, CALLBACK_BLOCK):
+		if !isChatAdmin(bot, query.Message.Chat, query.From) {
+			answer = "Only admins can change the settings."
+			break
+		}
+		topic := strings.TrimPrefix(query.Data, CALLBACK_BLOCK)
+		state.blockTopic(id, topic)
+		if _, err := bot.Request(tgbotapi.NewEditMessageText(id, messageId, state.blockedTopics(id))); err != nil {
+			log.Println("Couldn't update the block prompt in", id, ":", err)
+		}
+		refreshPinnedSettings(bot, state, id)
+	case strings.HasPrefix(query.Data, CALLBACK_BLOCK_TOPIC):
+		if !isChatAdmin(bot, query.Message.Chat, query.From) {
+			answer = "Only admins can change the settings."
+			break
+		}
+		topic := strings.TrimPrefix(query.Data, CALLBACK_BLOCK_TOPIC)
+		state.blockTopic(id, topic)
+		answer = fmt.Sprintf("Blocked #%s, use /unblock %s to undo.", topic, topic)
+		// The notification stays, only the button is removed.
+		markup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+		if proposalId, ok := state.proposalForMessage(id, messageId); ok {
+			if proposal, ok := state.cachedProposal(proposalId); ok {
+				chat, _ := state.chat(id)
+				markup, _ = notificationKeyboard(state, proposal, chat)
+			}
+		}
+		if _, err := bot.Request(tgbotapi.NewEditMessageReplyMarkup(id, messageId, markup)); err != nil {
+			log.Println("Couldn't remove the block button in", id, ":", err)
+		}
+		refreshPinnedSettings(bot, state, id)
+	case strings.HasPrefix(query.Data, CALLBACK_SENTIMENT):
+		answer = handleSentiment(bot, shards, state, query)
+	case strings.HasPrefix(query.Data, CALLBACK_FEEDBACK):
+		answer = handleFeedback(bot, state, query)
+	case strings.HasPrefix(query.Data, CALLBACK_FULL_SUMMARY):
+		answer = handleFullSummary(shards, state, query)
+	}
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+		log.Println("Couldn't answer the callback query in", id, ":", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Records the final status of the announced proposal `id`.
+func (s *State) recordOutcome(id uint64, status string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, a := range s.Activity {
+		if a.Id == id {
+			a.Status = status
+		}
+	}
+}
+
+// Counts a reaction to a notification about the announced proposal `id`.
+func (s *State) recordReaction(id uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, a := range s.Activity {
+		if a.Id == id {
+			a.Reactions++
+		}
+	}
+}
+
+func (s *State) setWeeklyRecap(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.WeeklyRecap = enabled
+	return true
+}
+
+func (s *State) weeklyRecapChatIds() (res []int64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for id, chat := range s.ChatIds {
+		if chat.WeeklyRecap {
+			res = append(res, id)
+		}
+	}
+	return
+}
+
+// Returns copies of the records of the proposals announced since `since`.
+func (s *State) activitySince(since time.Time) (res []Activity) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, a := range s.Activity {
+		if a.Time.After(since) {
+			res = append(res, *a)
+		}
+	}
+	return
+}
+
+// Renders the number of proposals per topic, the decided proposals and the proposals with the
+// most reactions among the subscribers.
+func renderRecap(activity []Activity) string {
+	if len(activity) == 0 {
+		return "📅 <b>Weekly governance recap</b>\n\nNo proposals were submitted this week."
+	}
+	perTopic := map[string]int{}
+	var adopted, rejected []string
+	for _, a := range activity {
+		perTopic[a.Topic]++
+		line := fmt.Sprintf("%d: %s", a.Id, htmlTitle(a.Title))
+		switch a.Status {
+		case STATUS_ADOPTED, STATUS_EXECUTED:
+			adopted = append(adopted, line)
+		case STATUS_REJECTED:
+			rejected = append(rejected, line)
+		}
+	}
+	var topics []rank
+	for topic, count := range perTopic {
+		topics = append(topics, rank{topic, float64(count)})
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if topics[i].value == topics[j].value {
+			return topics[i].name < topics[j].name
+		}
+		return topics[i].value > topics[j].value
+	})
+	lines := []string{fmt.Sprintf("📅 <b>Weekly governance recap</b>\n\n%d proposals were submitted this week:", len(activity))}
+	for _, t := range topics {
+		lines = append(lines, fmt.Sprintf("#%s: %.0f", t.name, t.value))
+	}
+	section := func(title string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		if len(entries) > LEADERBOARD_SIZE {
+			entries = append(entries[:LEADERBOARD_SIZE:LEADERBOARD_SIZE], fmt.Sprintf("and %d more", len(entries)-LEADERBOARD_SIZE))
+		}
+		lines = append(lines, "\n"+title)
+		lines = append(lines, entries...)
+	}
+	section(fmt.Sprintf("✅ Adopted (%d):", len(adopted)), adopted)
+	section(fmt.Sprintf("🔴 Rejected (%d):", len(rejected)), rejected)
+	sort.SliceStable(activity, func(i, j int) bool { return activity[i].Reactions > activity[j].Reactions })
+	var discussed []string
+	for _, a := range activity {
+		if a.Reactions == 0 || len(discussed) == LEADERBOARD_SIZE {
+			break
+		}
+		discussed = append(discussed, fmt.Sprintf("%s (%d reactions)\n%s", htmlTitle(a.Title), a.Reactions, html.EscapeString(proposalURL(a.Id))))
+	}
+	section("💬 Most discussed:", discussed)
+	return strings.Join(lines, "\n")
+}
+
+// Returns the next time the weekly recaps are sent after `t`.
+func nextRecap(t time.Time) time.Time {
+	next := nextDigest(t)
+	for next.Weekday() != RECAP_WEEKDAY {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Sends the recap of the past week to all chats which opted in; scheduled every RECAP_WEEKDAY at
+// DIGEST_HOUR.
+func deliverWeeklyRecap(shards *Shards, state *State) {
+	text := renderRecap(state.activitySince(time.Now().Add(-7 * 24 * time.Hour)))
+	ids := state.weeklyRecapChatIds()
+	for _, id := range ids {
+		msg := tgbotapi.NewMessage(id, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		send(shards, state, msg)
+	}
+	log.Println("Sent the weekly recap to", len(ids), "users")
+}
+
+func weeklyRecapCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /weekly_recap on or /weekly_recap off"
+	}
+	if !r.state.setWeeklyRecap(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return fmt.Sprintf("You'll receive a recap of the past week every %s at %02d:00 UTC.", RECAP_WEEKDAY, DIGEST_HOUR)
+	}
+	return "Weekly recap disabled."
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ZERO_WIDTH_JOINER = '\u200d'
+	// Longest HTML entity we expect in a summary, e.g. `&#x1F600;`.
+	MAX_ENTITY_LENGTH = 10
+)
+
+var (
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)|<img[^>]*>`)
+	htmlCommentPattern   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	blankLinesPattern    = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+	htmlLinkPattern      = regexp.MustCompile(`<a href="([^"]*)">([^<]*)</a>`)
+	htmlTagPattern       = regexp.MustCompile(`</?(b|i|u|s|code|pre|a)( [^>]*)?>`)
+)
+
+// Returns the link to the proposal `id` by substituting it into PROPOSAL_URL_TEMPLATE.
+func proposalURL(id uint64) string {
+	return strings.ReplaceAll(PROPOSAL_URL_TEMPLATE, "{id}", fmt.Sprint(id))
+}
+
+// Renders the notification about `proposal` in the format chosen by `chat`.
+func renderProposal(proposal Proposal, chat Chat) string {
+	var text string
+	switch {
+	case chat.Template != "":
+		text = renderTemplate(proposal, chat)
+	case chat.Format == FORMAT_COMPACT:
+		text = renderCompact(proposal, chat)
+	default:
+		text = renderFull(proposal, chat)
+	}
+	if footer := chat.footer(); footer != "" {
+		text += "\n\n" + fillTemplate(footer, proposal, chat)
+	}
+	return text
+}
+
+// Renders a single line with the title, the topic and the link.
+func renderCompact(proposal Proposal, chat Chat) string {
+	return fmt.Sprintf("%s %s<b>%s</b> — %s — %s",
+		statusBadge(proposal), highlightMarker(proposal, chat), htmlTitle(proposal.Title), hashtags(proposal), htmlURL(proposal))
+}
+
+// Renders the title, the proposer or SNS, the summary shortened and highlighted according to the settings of `chat`,
+// the topic and the link. The result of the artifact verification is appended to the summary once known.
+func renderFull(proposal Proposal, chat Chat) string {
+	summary, truncated := truncateAtWord(sanitizeSummary(proposal.Summary), chat.summaryBudget())
+	summary = markdownToHTML(summary, chat.Highlights)
+	if truncated {
+		summary += fmt.Sprintf(` <a href="%s">read more</a>`, htmlURL(proposal))
+	}
+	if len(summary) > 0 {
+		summary = "\n" + summary + "\n"
+	}
+	if tldr := renderTLDR(proposal, chat); tldr != "" {
+		summary = "\n" + tldr + "\n" + summary
+	}
+	if payload := renderPayload(proposal); payload != "" {
+		summary += "\n" + payload
+	}
+	if annotation := artifactAnnotation(proposal); annotation != "" {
+		summary += "\n" + annotation + "\n"
+	}
+	origin := "Proposer: " + html.EscapeString(proposerName(proposal.Proposer))
+	if proposal.Source != "" {
+		origin = "SNS: " + html.EscapeString(proposal.SourceName)
+	}
+	return fmt.Sprintf("%s %s<b>%s</b>\n\n%s\n%s\n%s\n\n%s",
+		statusBadge(proposal), highlightMarker(proposal, chat), htmlTitle(proposal.Title), origin, summary, hashtags(proposal), htmlURL(proposal))
+}
+
+// Returns an emoji representing the state of the proposal: 🟢 open, 🟡 open with the voting
+// deadline approaching, 🔴 rejected, ✅ adopted or executed and ⚠️ failed.
+func statusBadge(proposal Proposal) string {
+	switch proposal.Status {
+	case STATUS_REJECTED:
+		return "🔴"
+	case STATUS_ADOPTED, STATUS_EXECUTED:
+		return "✅"
+	case STATUS_FAILED:
+		return "⚠️"
+	}
+	if proposal.Deadline > 0 && time.Until(time.Unix(proposal.Deadline, 0)) < DECIDING_SOON {
+		return "🟡"
+	}
+	return "🟢"
+}
+
+// Returns the title truncated to MAX_TITLE_LENGTH characters.
+func shortTitle(title string) string {
+	if short, truncated := truncate(title, MAX_TITLE_LENGTH-1); truncated {
+		return short + "…"
+	}
+	return title
+}
+
+// Returns the title shortened with shortTitle and escaped for the HTML parse mode.
+func htmlTitle(title string) string {
+	return html.EscapeString(shortTitle(title))
+}
+
+// Returns the link to `proposal` escaped for the HTML parse mode, as custom URL templates may
+// contain `&`.
+func htmlURL(proposal Proposal) string {
+	return html.EscapeString(urlOf(proposal))
+}
+
+// Truncates `text` to at most `limit` characters at the last word boundary and appends an
+// ellipsis. Returns true if the text was truncated.
+func truncateAtWord(text string, limit int) (string, bool) {
+	short, truncated := truncate(text, limit-1)
+	if !truncated {
+		return text, false
+	}
+	if cut := strings.LastIndexAny(short, " \n\t"); cut > 0 {
+		short = short[:cut]
+	}
+	return strings.TrimRight(short, " \n\t.,;:") + "…", true
+}
+
+// Truncates `text` to at most `limit` characters. Counts runes instead of bytes and never
+// splits a multi-byte character, a joined emoji sequence or an HTML entity like `&amp;`.
+// Returns true if the text was truncated.
+func truncate(text string, limit int) (string, bool) {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text, false
+	}
+	runes = runes[:limit]
+	// Drop the dangling parts of an emoji sequence or a character with combining marks.
+	for len(runes) > 0 {
+		last := runes[len(runes)-1]
+		if last != ZERO_WIDTH_JOINER && !unicode.Is(unicode.Mn, last) && !unicode.Is(unicode.Variation_Selector, last) {
+			break
+		}
+		runes = runes[:len(runes)-1]
+	}
+	res := string(runes)
+	if amp := strings.LastIndex(res, "&"); amp >= 0 && !strings.Contains(res[amp:], ";") && len(res)-amp <= MAX_ENTITY_LENGTH {
+		res = res[:amp]
+	}
+	return res, true
+}
+
+// Removes images, HTML comments and excessive blank lines from a markdown summary, as they
+// only render as noise in Telegram.
+func sanitizeSummary(summary string) string {
+	summary = htmlCommentPattern.ReplaceAllString(summary, "")
+	summary = markdownImagePattern.ReplaceAllString(summary, "")
+	summary = blankLinesPattern.ReplaceAllString(summary, "\n\n")
+	return strings.TrimSpace(summary)
+}
+
+// Converts a message rendered for the HTML parse mode to plain text: links are written out
+// after their text, the formatting tags we use are removed and entities are unescaped.
+func htmlToPlainText(text string) string {
+	text = htmlLinkPattern.ReplaceAllString(text, "$2 ($1)")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
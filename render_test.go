@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		limit         int
+		want          string
+		wantTruncated bool
+	}{
+		{"short", "hello", 10, "hello", false},
+		{"exact", "hello", 5, "hello", false},
+		{"ascii", "hello world", 5, "hello", true},
+		{"counts runes", "äöüäöü", 3, "äöü", true},
+		{"joined emoji", "ab👩‍💻", 4, "ab👩", true},
+		{"trailing variation selector", "ab❤️!", 4, "ab❤", true},
+		{"combining mark", "abé", 3, "abe", true},
+		{"entity", "a &amp; b", 4, "a ", true},
+		{"complete entity", "a &amp; b", 7, "a &amp;", true},
+		{"ampersand without entity", "a & " + "bbbbbbbbbbbbbbbb", 18, "a & bbbbbbbbbbbbbb", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncate(tt.text, tt.limit)
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("truncate(%q, %d) = %q, %v, want %q, %v", tt.text, tt.limit, got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Number of the most recent errors kept per delivery record.
+const MAX_DELIVERY_ERRORS = 5
+
+// Record of how a proposal was delivered to a chat.
+type Delivery struct {
+	ProposalId uint64 `json:"proposal_id"`
+	// Namespace of the governance system of the proposal; empty for the NNS.
+	Source        string     `json:"source,omitempty"`
+	Deferred      *time.Time `json:"deferred,omitempty"`
+	Sent          *time.Time `json:"sent,omitempty"`
+	MessageId     int        `json:"message_id,omitempty"`
+	Edits         int        `json:"edits,omitempty"`
+	StatusUpdates int        `json:"status_updates,omitempty"`
+	Errors        []string   `json:"errors,omitempty"`
+	// Fingerprint of the announced content, used to suppress duplicates.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Pinned      bool   `json:"pinned,omitempty"`
+	// Tally and status last shown by editing the notification.
+	Tally string `json:"tally,omitempty"`
+}
+
+// Returns true if this is the record of proposal `proposalId` of the governance system `source`.
+func (d *Delivery) of(source string, proposalId uint64) bool {
+	return d.ProposalId == proposalId && d.Source == source
+}
+
+// Returns the delivery record of the NNS proposal `proposalId` in `chat`, creating it if needed.
+// Expects the lock to be held.
+func (chat *Chat) delivery(proposalId uint64) *Delivery {
+	return chat.deliveryOf("", proposalId)
+}
+
+// Returns the delivery record of proposal `proposalId` of the governance system `source` in
+// `chat`, creating it if needed. Only the most recent MAX_DELIVERY_RECORDS records are kept per
+// chat. Expects the lock to be held.
+func (chat *Chat) deliveryOf(source string, proposalId uint64) *Delivery {
+	for _, d := range chat.Deliveries {
+		if d.of(source, proposalId) {
+			return d
+		}
+	}
+	d := &Delivery{ProposalId: proposalId, Source: source}
+	chat.Deliveries = append(chat.Deliveries, d)
+	if len(chat.Deliveries) > MAX_DELIVERY_RECORDS {
+		chat.Deliveries = chat.Deliveries[len(chat.Deliveries)-MAX_DELIVERY_RECORDS:]
+	}
+	return d
+}
+
+// Returns a fingerprint of the title and summary of `proposal` which ignores whitespace changes.
+func fingerprint(proposal Proposal) string {
+	content := strings.Join(strings.Fields(proposal.Title+" "+proposal.Summary), " ")
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:8])
+}
+
+// Returns false if chat `id` was already notified about `proposal` (or the notification was
+// deferred) within DEDUP_WINDOW and the content didn't change since; otherwise remembers the
+// content for the next check.
+func (s *State) shouldNotify(id int64, proposal Proposal) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	d := chat.deliveryOf(proposal.Source, proposal.Id)
+	fp := fingerprint(proposal)
+	for _, t := range []*time.Time{d.Sent, d.Deferred} {
+		if t != nil && time.Since(*t) < DEDUP_WINDOW && d.Fingerprint == fp {
+			return false
+		}
+	}
+	d.Fingerprint = fp
+	return true
+}
+
+// Records the result of sending the notification about proposal `proposalId` of the governance
+// system `source` to chat `id`. Only the last MAX_DELIVERY_ERRORS errors are kept. `messageId` is 0
+// for messages listing several proposals, like digests, which must not be edited for one of them;
+// the message of an earlier notification is kept then.
+func (s *State) recordDelivery(id int64, source string, proposalId uint64, messageId int, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return
+	}
+	d := chat.deliveryOf(source, proposalId)
+	if err != nil {
+		d.Errors = append(d.Errors, fmt.Sprintf("%s: %v", time.Now().UTC().Format(time.RFC3339), err))
+		if len(d.Errors) > MAX_DELIVERY_ERRORS {
+			d.Errors = d.Errors[len(d.Errors)-MAX_DELIVERY_ERRORS:]
+		}
+		return
+	}
+	now := time.Now()
+	d.Sent = &now
+	if messageId != 0 {
+		d.MessageId = messageId
+	}
+}
+
+// Records that the notification about `proposal` was deferred for chat `id`.
+func (s *State) recordDeferral(id int64, proposal Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		now := time.Now()
+		chat.deliveryOf(proposal.Source, proposal.Id).Deferred = &now
+	}
+}
+
+// Records that a status update about `proposal` was sent to chat `id`.
+func (s *State) recordStatusUpdate(id int64, proposal Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.deliveryOf(proposal.Source, proposal.Id).StatusUpdates++
+	}
+}
+
+// Returns the delivery report of `proposalId` for chat `id`.
+func (s *State) deliveryReport(id int64, proposalId uint64) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return "This chat is not subscribed."
+	}
+	var d *Delivery
+	for _, record := range chat.Deliveries {
+		if record.of("", proposalId) {
+			d = record
+		}
+	}
+	if d == nil {
+		return fmt.Sprintf("There is no delivery record of proposal %d in this chat.", proposalId)
+	}
+	lines := []string{fmt.Sprintf("Delivery report of proposal %d:", proposalId)}
+	if d.Deferred != nil {
+		lines = append(lines, "Deferred: "+formatTime(*d.Deferred, *chat))
+	}
+	if d.Sent != nil && d.MessageId == 0 {
+		lines = append(lines, fmt.Sprintf("Posted: %s (in a list of proposals)", formatTime(*d.Sent, *chat)))
+	} else if d.Sent != nil {
+		lines = append(lines, fmt.Sprintf("Posted: %s (message %d)", formatTime(*d.Sent, *chat), d.MessageId))
+	} else {
+		lines = append(lines, "Posted: not yet")
+	}
+	lines = append(lines, fmt.Sprintf("Edits: %d", d.Edits), fmt.Sprintf("Status updates: %d", d.StatusUpdates))
+	if len(d.Errors) == 0 {
+		lines = append(lines, "Errors: none")
+	} else {
+		lines = append(lines, "Errors:\n"+strings.Join(d.Errors, "\n"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldNotify(t *testing.T) {
+	proposal := Proposal{Id: 7, Title: "Upgrade the registry", Summary: "Fixes a bug."}
+	state := State{ChatIds: map[int64]*Chat{1: {}}}
+	if !state.shouldNotify(1, proposal) {
+		t.Fatal("shouldNotify() suppressed the first notification")
+	}
+	state.recordDelivery(1, "", 7, 100, nil)
+	tests := []struct {
+		name     string
+		chat     int64
+		proposal Proposal
+		want     bool
+	}{
+		{"unknown chat", 2, proposal, false},
+		{"already sent", 1, proposal, false},
+		{"whitespace changed", 1, Proposal{Id: 7, Title: "Upgrade  the registry", Summary: "Fixes a bug.\n"}, false},
+		{"summary changed", 1, Proposal{Id: 7, Title: "Upgrade the registry", Summary: "Fixes two bugs."}, true},
+		{"other proposal", 1, Proposal{Id: 8, Title: "Upgrade the registry", Summary: "Fixes a bug."}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := state.shouldNotify(tt.chat, tt.proposal); got != tt.want {
+				t.Errorf("shouldNotify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldNotifyAfterWindow(t *testing.T) {
+	proposal := Proposal{Id: 7, Title: "Upgrade the registry"}
+	sent := time.Now().Add(-DEDUP_WINDOW - time.Minute)
+	state := State{ChatIds: map[int64]*Chat{1: {Deliveries: []*Delivery{{ProposalId: 7, Sent: &sent, Fingerprint: fingerprint(proposal)}}}}}
+	if !state.shouldNotify(1, proposal) {
+		t.Error("shouldNotify() suppressed a notification sent before the dedup window")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ballot of a neuron as listed in its recent ballots.
+type neuronBallot struct {
+	ProposalId uint64 `json:"proposal_id"`
+	Vote       int    `json:"vote"`
+}
+
+// Returns the proposals the neuron `id` recently voted on.
+func fetchNeuronBallots(id uint64) (map[uint64]bool, error) {
+	var resp struct {
+		Ballots []neuronBallot `json:"recent_ballots"`
+	}
+	if err := getGovernanceAPI(fmt.Sprintf("/neurons/%d", id), &resp); err != nil {
+		return nil, err
+	}
+	res := map[uint64]bool{}
+	for _, b := range resp.Ballots {
+		if b.Vote == VOTE_YES || b.Vote == VOTE_NO {
+			res[b.ProposalId] = true
+		}
+	}
+	return res, nil
+}
+
+// Links the neuron whose votes are checked for reward reminders to chat `id`; 0 disables the
+// reminders.
+func (s *State) setRewardNeuron(id int64, neuron uint64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.RewardNeuron = neuron
+	chat.RewardReminded = nil
+	return true
+}
+
+// Returns the chats due for a reward check, mapped to their linked neurons.
+func (s *State) rewardReminderChats() map[int64]uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	res := map[int64]uint64{}
+	for id, chat := range s.ChatIds {
+		if chat.RewardNeuron != 0 && (chat.RewardReminded == nil || time.Since(*chat.RewardReminded) > REWARD_REMINDER_INTERVAL) {
+			res[id] = chat.RewardNeuron
+		}
+	}
+	return res
+}
+
+func (s *State) markReminded(id int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		now := time.Now()
+		chat.RewardReminded = &now
+	}
+}
+
+// Returns the ids of the governance proposals announced since `since`.
+func (s *State) governanceProposalsSince(since time.Time) (res []uint64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, a := range s.Activity {
+		if a.Topic == TOPIC_GOVERNANCE && a.Time.After(since) {
+			res = append(res, a.Id)
+		}
+	}
+	return
+}
+
+// Reminds the chats whose linked neuron didn't vote on any governance proposal within
+// REWARD_WINDOW, as it is probably missing out on voting rewards; scheduled every REWARD_CHECK_INTERVAL.
+func checkRewards(shards *Shards, state *State) {
+	proposals := state.governanceProposalsSince(time.Now().Add(-REWARD_WINDOW))
+	if len(proposals) == 0 {
+		return
+	}
+	for id, neuron := range state.rewardReminderChats() {
+		voted, err := fetchNeuronBallots(neuron)
+		if err != nil {
+			log.Println("Couldn't fetch the ballots of neuron", neuron, ":", err)
+			continue
+		}
+		missed := 0
+		for _, proposalId := range proposals {
+			if !voted[proposalId] {
+				missed++
+			}
+		}
+		if missed < len(proposals) {
+			continue
+		}
+		text := fmt.Sprintf("Your neuron %d didn't vote on any of the %d governance proposals of the last %d days, "+
+			"so it is probably missing out on voting rewards. Vote in the NNS dapp or let the neuron follow "+
+			"another neuron on the Governance topic. Use /reward_reminders off to stop these reminders.",
+			neuron, len(proposals), int(REWARD_WINDOW.Hours()/24))
+		if _, err := send(shards, state, tgbotapi.NewMessage(id, text)); err == nil {
+			state.markReminded(id)
+		}
+	}
+}
+
+func rewardRemindersCommand(r *Request) string {
+	if len(r.args) == 1 && r.args[0] == "off" {
+		if !r.state.setRewardNeuron(r.id, 0) {
+			return NOT_SUBSCRIBED
+		}
+		return "You won't receive reward reminders anymore."
+	}
+	var neuron uint64
+	var err error
+	if len(r.args) == 1 {
+		neuron, err = strconv.ParseUint(r.args[0], 10, 64)
+	}
+	if len(r.args) != 1 || err != nil || neuron == 0 {
+		return "Please specify your neuron id or off"
+	}
+	if !r.state.setRewardNeuron(r.id, neuron) {
+		return NOT_SUBSCRIBED
+	}
+	return fmt.Sprintf("You'll be reminded if neuron %d doesn't vote on any governance proposal for %d days.",
+		neuron, int(REWARD_WINDOW.Hours()/24))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Recurring job; `next` returns the time of the first run after the given time.
+type Job struct {
+	Name string
+	next func(time.Time) time.Time
+	run  func()
+}
+
+// Runs the recurring jobs. The time of the next run of each job is persisted in the state, so
+// intervals don't start over with every restart and runs missed during a downtime happen right
+// after the restart.
+type Scheduler struct {
+	jobs []*Job
+}
+
+var scheduler Scheduler
+
+// Returns a schedule running a job every `interval`.
+func every(interval time.Duration) func(time.Time) time.Time {
+	return func(t time.Time) time.Time { return t.Add(interval) }
+}
+
+// Adds a job; must be called before the scheduler is started.
+func (s *Scheduler) add(name string, next func(time.Time) time.Time, run func()) {
+	s.jobs = append(s.jobs, &Job{name, next, run})
+}
+
+// Returns the time of the next run of job `name`, if it was scheduled before.
+func (s *State) nextRun(name string) (time.Time, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	t, ok := s.Jobs[name]
+	return t, ok
+}
+
+func (s *State) setNextRun(name string, t time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Jobs[name] = t
+}
+
+// Checks for due jobs every minute and runs them one after the other.
+func (s *Scheduler) start(state *State) {
+	for _, job := range s.jobs {
+		if _, ok := state.nextRun(job.Name); !ok {
+			state.setNextRun(job.Name, job.next(time.Now()))
+		}
+	}
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		for _, job := range s.jobs {
+			if next, _ := state.nextRun(job.Name); time.Now().Before(next) {
+				continue
+			}
+			log.Println("Running the scheduled job", job.Name)
+			job.run()
+			state.setNextRun(job.Name, job.next(time.Now()))
+		}
+	}
+}
+
+// Returns the upcoming runs of all jobs, sorted by time.
+func (s *Scheduler) upcoming(state *State) string {
+	type run struct {
+		name string
+		at   time.Time
+	}
+	var runs []run
+	for _, job := range s.jobs {
+		at, _ := state.nextRun(job.Name)
+		runs = append(runs, run{job.Name, at})
+	}
+	if len(runs) == 0 {
+		return "No jobs are scheduled."
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].at.Before(runs[j].at) })
+	lines := []string{"Upcoming runs:"}
+	for _, r := range runs {
+		lines = append(lines, fmt.Sprintf("%s: %s (in %s)", r.name, r.at.UTC().Format("2006-01-02 15:04 UTC"),
+			formatCountdown(time.Until(r.at))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Drops the tombstones, activity records and sentiment which are no longer needed.
+func (s *State) prune() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pruneTombstones()
+	s.pruneSentiment()
+	s.pruneAttestations()
+	cutoff := time.Now().Add(-STATS_WINDOW)
+	var activity []*Activity
+	for _, a := range s.Activity {
+		if a.Time.After(cutoff) {
+			activity = append(activity, a)
+		}
+	}
+	s.Activity = activity
+}
+
+// Registers the recurring jobs of the bot.
+func scheduleJobs(shards *Shards, state *State) {
+	scheduler.add("daily digest", nextDigest, func() { deliverDigests(shards, state) })
+	scheduler.add("weekly recap", nextRecap, func() { deliverWeeklyRecap(shards, state) })
+	scheduler.add("reward reminders", every(REWARD_CHECK_INTERVAL), func() { checkRewards(shards, state) })
+	scheduler.add("pruning", every(time.Hour), state.prune)
+	if len(TOPIC_COOLDOWNS) > 0 {
+		scheduler.add("cooldowns", every(time.Minute), func() { flushBursts(shards, state) })
+	}
+	scheduler.add("feedback poll", nextMonth, func() { askForFeedback(shards, state) })
+	scheduler.add("known neurons", every(KNOWN_NEURONS_REFRESH_INTERVAL), refreshKnownNeurons)
+	scheduler.add("tally refresh", every(TALLY_REFRESH_INTERVAL), func() { refreshLiveTallies(shards, state) })
+	if TELEMETRY_URL != "" {
+		scheduler.add("telemetry report", every(TELEMETRY_INTERVAL), func() { sendTelemetryReport(state) })
+	}
+	if SELF_TEST_CHAT_ID != 0 {
+		scheduler.add("self-test", every(SELF_TEST_INTERVAL), func() { runSelfTest(shards, state) })
+	}
+	if BACKUP_CANISTER_URL != "" {
+		scheduler.add("state backup", every(BACKUP_INTERVAL), func() { backupState(state) })
+	}
+}
+
+func jobsCommand(r *Request) string {
+	if ADMIN_CHAT_ID == 0 || r.id != ADMIN_CHAT_ID {
+		return "This command is only available in the admin chat."
+	}
+	return scheduler.upcoming(r.state)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Rules computing the importance score of a proposal. The defaults can be replaced by a JSON file
+// given in SCORING_RULES_PATH.
+type ScoringRules struct {
+	// Weight of each topic; topics without a weight get the default one.
+	TopicWeights       map[string]float64 `json:"topic_weights"`
+	DefaultTopicWeight float64            `json:"default_topic_weight"`
+	// Weights added for proposers with a known reputation.
+	ProposerWeights map[uint64]float64 `json:"proposer_weights"`
+	// Weights added for keywords in the title or summary hinting at a critical payload.
+	CriticalKeywords map[string]float64 `json:"critical_keywords"`
+	// Weight multiplied with the share of the voting power which didn't vote yet.
+	VotingPowerWeight float64 `json:"voting_power_weight"`
+	// Minimal score of the proposals delivered to chats in the important only mode.
+	Threshold float64 `json:"threshold"`
+}
+
+// Path of a JSON file with ScoringRules replacing the default rules.
+var SCORING_RULES_PATH = ""
+
+var scoringRules = ScoringRules{
+	TopicWeights: map[string]float64{
+		"Governance":                     3,
+		"NetworkEconomics":               3,
+		"ProtocolCanisterManagement":     2,
+		"ServiceNervousSystemManagement": 2,
+		"SnsAndCommunityFund":            2,
+		"IcOsVersionElection":            2,
+		"IcOsVersionDeployment":          2,
+		"NodeProviderRewards":            1.5,
+		"NodeAdmin":                      0.5,
+		"ExchangeRate":                   0,
+	},
+	DefaultTopicWeight: 1,
+	ProposerWeights:    map[uint64]float64{},
+	CriticalKeywords: map[string]float64{
+		"emergency":           3,
+		"security":            2,
+		"vulnerability":       2,
+		"governance canister": 2,
+		"ledger":              1.5,
+		"root canister":       1.5,
+		"registry":            1,
+		"upgrade":             0.5,
+	},
+	VotingPowerWeight: 1,
+	Threshold:         3,
+}
+
+// Replaces the default scoring rules with the ones in SCORING_RULES_PATH, if set.
+func loadScoringRules() {
+	if SCORING_RULES_PATH == "" {
+		return
+	}
+	data, err := os.ReadFile(SCORING_RULES_PATH)
+	if err != nil {
+		log.Fatalln("Couldn't read the scoring rules", SCORING_RULES_PATH, ":", err)
+	}
+	var rules ScoringRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Fatalln("Couldn't parse the scoring rules", SCORING_RULES_PATH, ":", err)
+	}
+	scoringRules = rules
+}
+
+// Returns the importance score of `proposal`.
+func importance(proposal Proposal) float64 {
+	score, ok := scoringRules.TopicWeights[proposal.Topic]
+	if !ok {
+		score = scoringRules.DefaultTopicWeight
+	}
+	score += scoringRules.ProposerWeights[proposal.Proposer]
+	content := strings.ToLower(proposal.Title + " " + proposal.Summary)
+	for keyword, weight := range scoringRules.CriticalKeywords {
+		if strings.Contains(content, strings.ToLower(keyword)) {
+			score += weight
+		}
+	}
+	if t := proposal.Tally; t != nil && t.Total > 0 {
+		score += scoringRules.VotingPowerWeight * (1 - (t.Yes+t.No)/t.Total)
+	}
+	return score
+}
+
+// Returns true if `proposal` is important enough to be delivered to `chat`.
+func important(chat *Chat, proposal Proposal) bool {
+	return !chat.ImportantOnly || importance(proposal) >= scoringRules.Threshold
+}
+
+// Switches the important only mode of chat `id`.
+func (s *State) setImportantOnly(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.ImportantOnly = enabled
+	return true
+}
+
+func importantOnlyCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please specify on or off"
+	}
+	if !r.state.setImportantOnly(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return fmt.Sprintf("You'll only receive proposals with an importance score of at least %g, "+
+			"based on their topic, proposer, payload and the voting power at stake.", scoringRules.Threshold)
+	}
+	return "You'll receive all proposals matching your filters again."
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns up to MAX_SEARCH_RESULTS cached proposals whose title or summary contains all `words`,
+// newest first.
+func (s *State) searchProposals(words []string) (res []Proposal) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for i := len(s.Recent) - 1; i >= 0 && len(res) < MAX_SEARCH_RESULTS; i-- {
+		p := s.Recent[i]
+		text := strings.ToLower(p.Title + "\n" + p.Summary)
+		matches := true
+		for _, w := range words {
+			if !strings.Contains(text, strings.ToLower(w)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			res = append(res, p)
+		}
+	}
+	return
+}
+
+func searchCommand(r *Request) string {
+	if len(r.args) == 0 {
+		return "Please specify what to search for, e.g. /search boundary node"
+	}
+	proposals := r.state.searchProposals(r.args)
+	if len(proposals) == 0 {
+		return fmt.Sprintf("None of the last %d proposals matches \"%s\".", MAX_CACHED_PROPOSALS, strings.Join(r.args, " "))
+	}
+	lines := []string{fmt.Sprintf("Proposals matching \"%s\":", strings.Join(r.args, " "))}
+	for _, p := range proposals {
+		lines = append(lines, fmt.Sprintf("%s %d: %s (%s)\n%s", statusBadge(p), p.Id, shortTitle(p.Title), hashtags(p), proposalURL(p.Id)))
+	}
+	return strings.Join(lines, "\n\n")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Synthetic proposal injected by the self-test.
+var selfTestProposal = Proposal{
+	Title:   "Self-test of the NNS proposals bot",
+	Topic:   TOPIC_GOVERNANCE,
+	Summary: "This proposal doesn't exist; it verifies that filtering, rendering and delivery work.",
+}
+
+// Runs the synthetic proposal through the filters, the renderer and the delivery by every bot to
+// SELF_TEST_CHAT_ID. Returns the failed stages.
+func selfTest(shards *Shards, state *State) (failures []string) {
+	chat := Chat{BlockedTopics: map[string]bool{}}
+	if !acceptsProposal(&chat, selfTestProposal) {
+		failures = append(failures, "filter: the default settings reject the proposal")
+	}
+	text := renderProposal(selfTestProposal, chat)
+	if !strings.Contains(text, selfTestProposal.Title) {
+		failures = append(failures, "render: the title is missing")
+	}
+	for _, bot := range shards.bots {
+		msg := tgbotapi.NewMessage(SELF_TEST_CHAT_ID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		msg.DisableNotification = true
+		// Sent like a notification, but by each bot in turn.
+		sent, err := send(singleBotShards(bot), state, msg)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("deliver: %s couldn't send: %v", bot.Self.UserName, err))
+			continue
+		}
+		if _, err := bot.Request(tgbotapi.NewDeleteMessage(SELF_TEST_CHAT_ID, sent.MessageID)); err != nil {
+			failures = append(failures, fmt.Sprintf("delete: %s couldn't delete the test message: %v", bot.Self.UserName, err))
+		}
+	}
+	return
+}
+
+// Alerts the admin chat through the first bot which is able to reach it.
+func alertAdmin(shards *Shards, text string) {
+	for _, bot := range shards.bots {
+		if _, err := bot.Send(tgbotapi.NewMessage(ADMIN_CHAT_ID, text)); err == nil {
+			return
+		}
+	}
+	log.Println("Couldn't alert the admin chat:", text)
+}
+
+// Runs the self-test and alerts the admin chat if any stage fails; scheduled every
+// SELF_TEST_INTERVAL.
+func runSelfTest(shards *Shards, state *State) {
+	failures := selfTest(shards, state)
+	if len(failures) == 0 {
+		log.Println("The self-test passed")
+		return
+	}
+	log.Println("The self-test failed:", failures)
+	if ADMIN_CHAT_ID != 0 {
+		alertAdmin(shards, "⚠️ The self-test failed:\n"+strings.Join(failures, "\n"))
+	}
+}
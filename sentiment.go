@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	SENTIMENT_ADOPT  = "adopt"
+	SENTIMENT_REJECT = "reject"
+	// Delay after a vote until the counts are updated in all chats.
+	SENTIMENT_REFRESH_DELAY = 30 * time.Second
+)
+
+// Records the sentiment of `user` on proposal `proposalId`; repeating the same vote withdraws it.
+// Returns the recorded sentiment or "" if it was withdrawn.
+func (s *State) voteSentiment(proposalId uint64, user int64, sentiment string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	votes := s.Sentiment[proposalId]
+	if votes == nil {
+		votes = map[int64]string{}
+		s.Sentiment[proposalId] = votes
+	}
+	if votes[user] == sentiment {
+		delete(votes, user)
+		return ""
+	}
+	votes[user] = sentiment
+	return sentiment
+}
+
+// Returns the number of subscribers in favor and against proposal `proposalId`.
+func (s *State) sentiment(proposalId uint64) (adopt, reject int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, sentiment := range s.Sentiment[proposalId] {
+		if sentiment == SENTIMENT_ADOPT {
+			adopt++
+		} else {
+			reject++
+		}
+	}
+	return
+}
+
+// Drops the sentiment of proposals which are no longer cached. Expects the lock to be held.
+func (s *State) pruneSentiment() {
+	cached := map[uint64]bool{}
+	for _, p := range s.Recent {
+		cached[p.Id] = true
+	}
+	for id := range s.Sentiment {
+		if !cached[id] {
+			delete(s.Sentiment, id)
+		}
+	}
+}
+
+// Returns the inline keyboard of notifications in `chat`: the sentiment buttons with the current counts
+// for NNS proposals, the button showing the full summary if it was shortened and the button blocking
+// the topic, unless the chat already blocked it.
+func notificationKeyboard(state *State, proposal Proposal, chat Chat) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	// SNS proposal ids overlap with NNS ones.
+	if proposal.Source == "" {
+		rows = append(rows, sentimentRow(state, proposal.Id))
+	}
+	if button, ok := fullSummaryButton(proposal, chat); ok {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+	if button, ok := blockTopicButton(proposal); ok && !chat.BlockedTopics[proposal.Topic] {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
+
+// Returns the sentiment buttons with the current counts of proposal `proposalId`.
+func sentimentRow(state *State, proposalId uint64) []tgbotapi.InlineKeyboardButton {
+	adopt, reject := state.sentiment(proposalId)
+	data := func(sentiment string) string {
+		return fmt.Sprintf("%s%d:%s", CALLBACK_SENTIMENT, proposalId, sentiment)
+	}
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👍 Adopt (%d)", adopt), data(SENTIMENT_ADOPT)),
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👎 Reject (%d)", reject), data(SENTIMENT_REJECT)),
+	)
+}
+
+// Returns `markup` with the current counts in its sentiment buttons; the other buttons stay.
+func withSentimentCounts(state *State, markup *tgbotapi.InlineKeyboardMarkup, proposalId uint64) tgbotapi.InlineKeyboardMarkup {
+	res := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if markup == nil {
+		return res
+	}
+	for _, row := range markup.InlineKeyboard {
+		if len(row) > 0 && row[0].CallbackData != nil && strings.HasPrefix(*row[0].CallbackData, CALLBACK_SENTIMENT) {
+			row = sentimentRow(state, proposalId)
+		}
+		res.InlineKeyboard = append(res.InlineKeyboard, row)
+	}
+	return res
+}
+
+// Proposals whose notifications are about to be updated with the new sentiment counts.
+var sentimentRefreshes = struct {
+	pending map[uint64]bool
+	lock    sync.Mutex
+}{pending: map[uint64]bool{}}
+
+// Updates the sentiment counts on all notifications about proposal `proposalId` after
+// SENTIMENT_REFRESH_DELAY, so a series of votes causes one edit per chat.
+func scheduleSentimentRefresh(shards *Shards, state *State, proposalId uint64) {
+	sentimentRefreshes.lock.Lock()
+	defer sentimentRefreshes.lock.Unlock()
+	if sentimentRefreshes.pending[proposalId] {
+		return
+	}
+	sentimentRefreshes.pending[proposalId] = true
+	time.AfterFunc(SENTIMENT_REFRESH_DELAY, func() {
+		sentimentRefreshes.lock.Lock()
+		delete(sentimentRefreshes.pending, proposalId)
+		sentimentRefreshes.lock.Unlock()
+		refreshSentiment(shards, state, proposalId)
+	})
+}
+
+func refreshSentiment(shards *Shards, state *State, proposalId uint64) {
+	proposal, err := state.findProposal(proposalId)
+	if err != nil {
+		return
+	}
+	for id, messageId := range state.recipients(proposal) {
+		chat, ok := state.chat(id)
+		if !ok {
+			continue
+		}
+		markup, ok := notificationKeyboard(state, proposal, chat)
+		if !ok {
+			continue
+		}
+		_, err := shards.botFor(id).Request(tgbotapi.NewEditMessageReplyMarkup(id, messageId, markup))
+		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			log.Println("Couldn't update the sentiment of proposal", proposalId, "in", id, ":", err)
+		}
+	}
+}
+
+// Records the sentiment expressed with a button and updates the counts on the message right
+// away and on the notifications in the other chats shortly after. Returns the answer to the
+// callback query.
+func handleSentiment(bot *tgbotapi.BotAPI, shards *Shards, state *State, query *tgbotapi.CallbackQuery) string {
+	parts := strings.Split(strings.TrimPrefix(query.Data, CALLBACK_SENTIMENT), ":")
+	if len(parts) != 2 || query.From == nil {
+		return ""
+	}
+	proposalId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || parts[1] != SENTIMENT_ADOPT && parts[1] != SENTIMENT_REJECT {
+		return ""
+	}
+	answer := "Your vote was withdrawn."
+	if state.voteSentiment(proposalId, query.From.ID, parts[1]) != "" {
+		answer = fmt.Sprintf("Thanks, you voted to %s proposal %d.", parts[1], proposalId)
+	}
+	id := query.Message.Chat.ID
+	var markup tgbotapi.InlineKeyboardMarkup
+	if proposal, ok := state.cachedProposal(proposalId); ok {
+		chat, _ := state.chat(id)
+		markup, _ = notificationKeyboard(state, proposal, chat)
+	} else {
+		markup = withSentimentCounts(state, query.Message.ReplyMarkup, proposalId)
+	}
+	scheduleSentimentRefresh(shards, state, proposalId)
+	if _, err := bot.Request(tgbotapi.NewEditMessageReplyMarkup(id, query.Message.MessageID, markup)); err != nil {
+		log.Println("Couldn't update the sentiment of proposal", proposalId, "in", id, ":", err)
+	}
+	return answer
+}
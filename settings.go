@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Renders the current configuration of a chat.
+func settingsText(chat Chat) string {
+	var topics []string
+	for topic, blocked := range chat.BlockedTopics {
+		if blocked {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	blocked := "none"
+	if len(topics) > 0 {
+		blocked = strings.Join(topics, ", ")
+	}
+	mode := "all topics"
+	if chat.FilterMode == FILTER_ONLY {
+		mode = "only " + strings.Join(sortedKeys(chat.OnlyTopics), ", ")
+	}
+	if chat.ImportantOnly {
+		mode += ", important only"
+	}
+	if chat.BroadcastList != "" {
+		mode += ", managed by the operator"
+	}
+	keywords := "none"
+	if len(chat.Keywords) > 0 || len(chat.BlockedKeywords) > 0 {
+		var parts []string
+		parts = append(parts, chat.Keywords...)
+		for _, k := range chat.BlockedKeywords {
+			parts = append(parts, "not "+k)
+		}
+		keywords = strings.Join(parts, ", ")
+	}
+	snses := "none"
+	if len(chat.Namespaces) > 0 {
+		var names []string
+		for _, name := range chat.Namespaces {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		snses = strings.Join(names, ", ")
+	}
+	window := "always"
+	if chat.Window != nil {
+		window = chat.Window.String()
+	}
+	if chat.Quiet != nil {
+		window += ", quiet " + chat.Quiet.String()
+	}
+	if chat.DeliveryMode == DELIVERY_DAILY {
+		window = "daily digest at " + digestTime(chat)
+	}
+	format := FORMAT_FULL
+	if chat.Format != "" {
+		format = chat.Format
+	}
+	if chat.Template != "" {
+		format = "template"
+	}
+	timezone := chat.location().String()
+	votes := "off"
+	if chat.KnownNeuronVotes {
+		votes = "on"
+	}
+	return fmt.Sprintf("⚙️ Notification settings of this chat\n\n"+
+		"Mode: %s\nBlocked topics: %s\nKeywords: %s\nSNSes: %s\nDelivery window: %s\nTime zone: %s\nFormat: %s\nSummary length: %d\nKnown neuron votes: %s",
+		mode, blocked, keywords, snses, window, timezone, format, chat.summaryLength(), votes)
+}
+
+// Sends and pins the settings message in chat `id`.
+func pinSettings(bot *tgbotapi.BotAPI, state *State, id int64) error {
+	chat, ok := state.chat(id)
+	if !ok {
+		return fmt.Errorf("chat %d is not subscribed", id)
+	}
+	text := settingsText(chat)
+	sent, err := bot.Send(tgbotapi.NewMessage(id, text))
+	if err != nil {
+		return err
+	}
+	if _, err := bot.Request(tgbotapi.PinChatMessageConfig{ChatID: id, MessageID: sent.MessageID, DisableNotification: true}); err != nil {
+		return err
+	}
+	state.setPinnedSettings(id, sent.MessageID, text)
+	return nil
+}
+
+// Unpins the settings message in chat `id`.
+func unpinSettings(bot *tgbotapi.BotAPI, state *State, id int64) {
+	chat, ok := state.chat(id)
+	if !ok || chat.SettingsMessageId == 0 {
+		return
+	}
+	if _, err := bot.Request(tgbotapi.UnpinChatMessageConfig{ChatID: id, MessageID: chat.SettingsMessageId}); err != nil {
+		log.Println("Couldn't unpin the settings message in", id, ":", err)
+	}
+	state.setPinnedSettings(id, 0, "")
+}
+
+// Edits the pinned settings message of chat `id` if the configuration changed since it was
+// last rendered.
+func refreshPinnedSettings(bot *tgbotapi.BotAPI, state *State, id int64) {
+	chat, ok := state.chat(id)
+	if !ok || chat.SettingsMessageId == 0 {
+		return
+	}
+	text := settingsText(chat)
+	if text == chat.SettingsText {
+		return
+	}
+	if _, err := bot.Request(tgbotapi.NewEditMessageText(id, chat.SettingsMessageId, text)); err != nil {
+		log.Println("Couldn't update the settings message in", id, ":", err)
+		return
+	}
+	state.setPinnedSettings(id, chat.SettingsMessageId, text)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Number of points every bot occupies on the hash ring; more points spread the chats more evenly.
+const RING_POINTS_PER_BOT = 100
+
+type ringPoint struct {
+	hash uint32
+	bot  int
+}
+
+// Update extended by the update types which the library doesn't support yet.
+type Update struct {
+	tgbotapi.Update
+	MessageReaction *MessageReaction `json:"message_reaction"`
+}
+
+// Update received by one of the bots.
+type botUpdate struct {
+	bot    *tgbotapi.BotAPI
+	update Update
+}
+
+// Set of bots sharing the same state. Private chats are served by the bot the user talks to.
+// Groups and channels are assigned to the bot delivering their notifications by consistent
+// hashing on the chat id, so adding or removing a token only moves the chats of the affected
+// bot. All bots need to be able to post in the groups and channels assigned to them, e.g. by
+// being admins of the same channels.
+type Shards struct {
+	bots   []*tgbotapi.BotAPI
+	byName map[string]*tgbotapi.BotAPI
+	ring   []ringPoint
+	// State providing the bots of the private chats; set once the state is restored.
+	state *State
+}
+
+func hash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// Instantiates one bot per token, separated by commas. Points on the ring are derived from bot
+// user names rather than from the tokens, so a rotated token keeps its chats.
+func newShards(tokens string) (*Shards, error) {
+	s := Shards{byName: map[string]*tgbotapi.BotAPI{}}
+	for _, token := range strings.Split(tokens, ",") {
+		bot, err := tgbotapi.NewBotAPI(strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Authorized on account %s", bot.Self.UserName)
+		for i := 0; i < RING_POINTS_PER_BOT; i++ {
+			s.ring = append(s.ring, ringPoint{hash(fmt.Sprintf("%s#%d", bot.Self.UserName, i)), len(s.bots)})
+		}
+		s.bots = append(s.bots, bot)
+		s.byName[bot.Self.UserName] = bot
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
+	return &s, nil
+}
+
+// Returns shards consisting of `bot` only, to send a message through this bot.
+func singleBotShards(bot *tgbotapi.BotAPI) *Shards {
+	return &Shards{
+		bots:   []*tgbotapi.BotAPI{bot},
+		byName: map[string]*tgbotapi.BotAPI{bot.Self.UserName: bot},
+		ring:   []ringPoint{{0, 0}},
+	}
+}
+
+// Returns the bot responsible for delivering notifications to chat `id`. Private chats, which
+// have positive ids, are served by the bot which last received an update from them, if it's
+// still one of the bots.
+func (s *Shards) botFor(id int64) *tgbotapi.BotAPI {
+	if id > 0 && s.state != nil {
+		if bot := s.byName[s.state.chatBot(id)]; bot != nil {
+			return bot
+		}
+	}
+	h := hash(fmt.Sprint(id))
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.bots[s.ring[i].bot]
+}
+
+// Returns the user name of the bot serving the private chat `id`, if known.
+func (s *State) chatBot(id int64) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		return chat.Bot
+	}
+	return ""
+}
+
+// Records that the user of the private chat `id` talks to `bot`. Chats subscribed before the
+// bots were recorded are migrated with their next update.
+func (s *State) setChatBot(id int64, bot string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil && chat.Bot != bot {
+		chat.Bot = bot
+		log.Println("Private chat", id, "is now served by", bot)
+	}
+}
+
+// Merges the updates received by all bots into one channel. Every bot resumes at its persisted
+// offset, so updates received while the bot was down are handled, but none twice.
+func (s *Shards) updates(config tgbotapi.UpdateConfig, state *State) <-chan botUpdate {
+	res := make(chan botUpdate)
+	for _, bot := range s.bots {
+		config.Offset = state.updateOffset(bot.Self.UserName)
+		go pollUpdates(bot, config, res)
+	}
+	return res
+}
+
+// Returns the offset of the first update of `bot` which wasn't handled yet.
+func (s *State) updateOffset(bot string) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.UpdateOffsets[bot]
+}
+
+func (s *State) setUpdateOffset(bot string, offset int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.UpdateOffsets[bot] = offset
+}
+
+// Long-polls the updates of `bot`. Unlike the polling of the library, this decodes the update
+// types it doesn't know yet, like reactions.
+func pollUpdates(bot *tgbotapi.BotAPI, config tgbotapi.UpdateConfig, res chan<- botUpdate) {
+	for {
+		resp, err := bot.Request(config)
+		var updates []Update
+		if err == nil {
+			err = json.Unmarshal(resp.Result, &updates)
+		}
+		if err != nil {
+			log.Println("Couldn't get the updates of", bot.Self.UserName, ", retrying in 3 seconds:", err)
+			time.Sleep(3 * time.Second)
+			continue
+		}
+		for _, update := range updates {
+			if update.UpdateID >= config.Offset {
+				config.Offset = update.UpdateID + 1
+				res <- botUpdate{bot, update}
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSingleBotShards(t *testing.T) {
+	bot := &tgbotapi.BotAPI{Self: tgbotapi.User{UserName: "second_bot"}}
+	shards := singleBotShards(bot)
+	for _, id := range []int64{42, -100123, 0} {
+		if got := shards.botFor(id); got != bot {
+			t.Errorf("botFor(%d) = %v, want the only bot", id, got)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	SNS_API_URL           = "https://sns-api.internetcomputer.org/api/v1"
+	SNS_PROPOSAL_URL      = "https://nns.ic0.app/proposal/?u={root}&proposal={id}"
+	MAX_SNS_SUBSCRIPTIONS = 20
+)
+
+// SNS DAO as listed by the SNS API.
+type snsInfo struct {
+	RootCanisterId string `json:"root_canister_id"`
+	Name           string `json:"name"`
+}
+
+type snsProposal struct {
+	Id      uint64 `json:"proposal_id"`
+	Title   string `json:"proposal_title"`
+	Summary string `json:"summary"`
+	Action  string `json:"action_name"`
+	Status  string `json:"status"`
+}
+
+func getSNSAPI(path string, v interface{}) (err error) {
+	defer func(start time.Time) { metrics.observeRequest(SOURCE_SNS_API, start, err) }(time.Now())
+	resp, err := apiClient.Get(SNS_API_URL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// SNS DAO, namespaced by its root canister id.
+type snsGovernance struct {
+	root, name string
+}
+
+func (g snsGovernance) Namespace() string { return g.root }
+
+func (g snsGovernance) Name() string { return g.name }
+
+func (g snsGovernance) ListProposals() ([]Proposal, error) { return fetchSNSProposals(g.root, g.name) }
+
+func (g snsGovernance) GetProposal(id uint64) (Proposal, error) {
+	var p snsProposal
+	if err := getSNSAPI(fmt.Sprintf("/snses/%s/proposals/%d", g.root, id), &p); err != nil {
+		return Proposal{}, err
+	}
+	return p.toProposal(g.root, g.name), nil
+}
+
+// SNS proposals have no topics, so the qualified actions of the recent proposals are returned.
+func (g snsGovernance) Topics() ([]string, error) {
+	proposals, err := g.ListProposals()
+	return topicsOf(proposals), err
+}
+
+// Returns the SNS subscribed to as `namespace`, which is the id of its root canister.
+func openSNS(namespace, name string) (Governance, bool) {
+	return snsGovernance{namespace, name}, strings.HasSuffix(namespace, "-cai")
+}
+
+// Finds the SNS with the given name (case-insensitive) or root canister id.
+func findSNS(query string) (Governance, error) {
+	var resp struct {
+		Data []snsInfo `json:"data"`
+	}
+	if err := getSNSAPI("/snses?limit=100", &resp); err != nil {
+		return nil, err
+	}
+	for _, sns := range resp.Data {
+		if strings.EqualFold(sns.Name, query) || sns.RootCanisterId == query {
+			return snsGovernance{sns.RootCanisterId, sns.Name}, nil
+		}
+	}
+	return nil, fmt.Errorf("there is no SNS named %q", query)
+}
+
+func (p snsProposal) toProposal(root, name string) Proposal {
+	action := p.Action
+	if action == "" {
+		action = "Proposal"
+	}
+	return Proposal{
+		Title:      p.Title,
+		Topic:      snsTopic(name, action),
+		Id:         p.Id,
+		Summary:    p.Summary,
+		Status:     strings.ToUpper(p.Status),
+		Action:     action,
+		Source:     root,
+		SourceName: name,
+	}
+}
+
+// Returns the most recent proposals of the SNS with the root canister `root`.
+func fetchSNSProposals(root, name string) ([]Proposal, error) {
+	var resp struct {
+		Data []snsProposal `json:"data"`
+	}
+	if err := getSNSAPI(fmt.Sprintf("/snses/%s/proposals?limit=50", root), &resp); err != nil {
+		return nil, err
+	}
+	var res []Proposal
+	for _, p := range resp.Data {
+		res = append(res, p.toProposal(root, name))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+	return res, nil
+}
+
+// Qualifies the action of an SNS proposal with the name of the SNS, so that topic filters like
+// `/block OpenChat_Motion` don't apply to other DAOs. Only letters and digits are kept to form a
+// valid hashtag.
+func snsTopic(name, action string) string {
+	keep := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	return keep(name) + "_" + keep(action)
+}
+
+// Returns the link to `proposal`, considering the governance system it belongs to.
+func urlOf(proposal Proposal) string {
+	if proposal.Source == "" {
+		return proposalURL(proposal.Id)
+	}
+	return strings.NewReplacer("{root}", proposal.Source, "{id}", fmt.Sprint(proposal.Id)).Replace(SNS_PROPOSAL_URL)
+}
+
+// Subscribes chat `id` to the proposals of the governance system `g`.
+func (s *State) subscribe(id int64, g Governance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	if len(chat.Namespaces) >= MAX_SNS_SUBSCRIPTIONS {
+		return fmt.Errorf("you can subscribe to at most %d DAOs", MAX_SNS_SUBSCRIPTIONS)
+	}
+	if chat.Namespaces == nil {
+		chat.Namespaces = map[string]string{}
+	}
+	chat.Namespaces[g.Namespace()] = g.Name()
+	return nil
+}
+
+// Unsubscribes chat `id` from the governance system with the given name or namespace.
+func (s *State) unsubscribe(id int64, query string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	for namespace, name := range chat.Namespaces {
+		if namespace == query || strings.EqualFold(name, query) {
+			delete(chat.Namespaces, namespace)
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the governance systems any chat is subscribed to.
+func (s *State) subscribedGovernances() (res []Governance) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	seen := map[string]bool{}
+	for _, chat := range s.ChatIds {
+		for namespace, name := range chat.Namespaces {
+			if seen[namespace] {
+				continue
+			}
+			seen[namespace] = true
+			if g, ok := openGovernance(namespace, name); ok {
+				res = append(res, g)
+			} else {
+				log.Println("Couldn't find the governance system of namespace", namespace)
+			}
+		}
+	}
+	return
+}
+
+// Returns the newest proposal seen in `namespace` and whether it was polled before.
+func (s *State) lastSeenProposalIn(namespace string) (uint64, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	id, ok := s.LastSeenProposals[namespace]
+	return id, ok
+}
+
+// Atomic compare and swap for a new seen proposal id in `namespace`.
+func (s *State) setNewLastSeenIdIn(namespace string, id uint64) (updated bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if last, ok := s.LastSeenProposals[namespace]; !ok || last < id {
+		s.LastSeenProposals[namespace] = id
+		updated = true
+	}
+	return
+}
+
+// Returns the chats subscribed to the governance system of `proposal` which don't filter it out.
+// The NNS topic modes don't apply, as subscribing to another system is an explicit choice.
+func (s *State) chatIdsForSourceProposal(proposal Proposal) (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if _, ok := chat.Namespaces[proposal.Source]; ok && !chat.BlockedTopics[proposal.Topic] && acceptsKeywords(chat, proposal) {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Periodically polls the governance systems other than the NNS which have subscribers and
+// publishes their new proposals like the NNS ones. On the first poll of a system, only its newest
+// proposal id is recorded.
+func fetchSubscribedProposalsAndNotify(state *State) {
+	ticker := time.NewTicker(NNS_POLL_INTERVALL)
+	for range ticker.C {
+		for _, g := range state.subscribedGovernances() {
+			proposals, err := g.ListProposals()
+			if err != nil {
+				log.Println("Couldn't fetch the proposals of", g.Name(), ":", err)
+				continue
+			}
+			_, polled := state.lastSeenProposalIn(g.Namespace())
+			for _, proposal := range proposals {
+				if !state.setNewLastSeenIdIn(g.Namespace(), proposal.Id) || !polled {
+					continue
+				}
+				log.Println("New", g.Name(), "proposal detected:", proposal)
+				holdOrPublish(state, proposal)
+			}
+		}
+	}
+}
+
+func subscribeSNSCommand(r *Request) string {
+	if len(r.args) == 0 {
+		chat, ok := r.state.chat(r.id)
+		if !ok {
+			return NOT_SUBSCRIBED
+		}
+		if len(chat.Namespaces) == 0 {
+			return "You're not subscribed to any SNS. Subscribe with e.g. /subscribe_sns OpenChat."
+		}
+		var names []string
+		for _, name := range chat.Namespaces {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "You're subscribed to the proposals of: " + strings.Join(names, ", ")
+	}
+	g, err := findGovernance(strings.Join(r.args, " "))
+	if err != nil {
+		return fmt.Sprintf("Couldn't find the SNS: %v", err)
+	}
+	if err := r.state.subscribe(r.id, g); err != nil {
+		return fmt.Sprintf("Couldn't subscribe: %v", err)
+	}
+	reply := fmt.Sprintf("You'll receive the proposals of %s from now on.", g.Name())
+	topics, err := g.Topics()
+	if err != nil || len(topics) == 0 {
+		return reply + fmt.Sprintf(" Block an action of this SNS with e.g. /block %s.", snsTopic(g.Name(), "Motion"))
+	}
+	return reply + fmt.Sprintf(" Its recent proposals have the topics #%s; block one with e.g. /block %s.",
+		strings.Join(topics, ", #"), topics[0])
+}
+
+func unsubscribeSNSCommand(r *Request) string {
+	if len(r.args) == 0 {
+		return "Please specify the name or root canister id of the SNS"
+	}
+	if !r.state.unsubscribe(r.id, strings.Join(r.args, " ")) {
+		return "You're not subscribed to this SNS."
+	}
+	return "Unsubscribed from the SNS."
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Governance system whose proposals the bot relays. The NNS is always relayed; other systems
+// are surfaced as namespaces chats subscribe to.
+type Governance interface {
+	// Unique key of the governance system. Proposals of systems other than the NNS carry it as
+	// their source.
+	Namespace() string
+	Name() string
+	// Returns the most recent proposals, sorted by id.
+	ListProposals() ([]Proposal, error)
+	GetProposal(id uint64) (Proposal, error)
+	// Returns the topics the proposals of the system can have.
+	Topics() ([]string, error)
+}
+
+// Kind of governance systems chats can subscribe to, like the SNSes.
+type governanceKind struct {
+	// Finds the system with the given name or namespace.
+	find func(query string) (Governance, error)
+	// Returns the system a chat subscribed to as `namespace` and `name`, if it's of this kind.
+	open func(namespace, name string) (Governance, bool)
+}
+
+// Kinds of the governance systems other than the NNS. Other systems are plugged in by adding
+// their kind.
+var governanceKinds = []governanceKind{{findSNS, openSNS}}
+
+// Returns the governance system matching `query` from the first kind which knows it.
+func findGovernance(query string) (Governance, error) {
+	var errs []error
+	for _, kind := range governanceKinds {
+		g, err := kind.find(query)
+		if err == nil {
+			return g, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("%v", errs)
+}
+
+// Returns the governance system subscribed to as `namespace` and `name`, or false if no kind
+// knows the namespace.
+func openGovernance(namespace, name string) (Governance, bool) {
+	for _, kind := range governanceKinds {
+		if g, ok := kind.open(namespace, name); ok {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+var NNS Governance = nnsGovernance{}
+
+// Returns a key identifying `proposal` across the governance systems, whose proposal ids overlap.
+func proposalKey(proposal Proposal) string {
+	return fmt.Sprintf("%s/%d", proposal.Source, proposal.Id)
+}
+
+// Fetches the current version of `proposal` from the governance system it belongs to.
+func currentVersionOf(proposal Proposal) (Proposal, error) {
+	if proposal.Source == "" {
+		return NNS.GetProposal(proposal.Id)
+	}
+	g, ok := openGovernance(proposal.Source, proposal.SourceName)
+	if !ok {
+		return Proposal{}, fmt.Errorf("unknown governance system %s", proposal.Source)
+	}
+	return g.GetProposal(proposal.Id)
+}
+
+// The NNS, with new proposals coming from the proposal feed and the details from the governance
+// API.
+type nnsGovernance struct{}
+
+var NNS_TOPICS = []string{
+	"ApiBoundaryNodeManagement", "ExchangeRate", "Governance", "IcOsVersionDeployment", "IcOsVersionElection", "Kyc",
+	"NetworkCanisterManagement", "NetworkEconomics", "NeuronManagement", "NodeAdmin", "NodeProviderRewards",
+	"ParticipantManagement", "ProtocolCanisterManagement", "ServiceNervousSystemManagement", "SnsAndCommunityFund",
+	"SubnetManagement", "SubnetRental",
+}
+
+func (nnsGovernance) Namespace() string { return "" }
+
+func (nnsGovernance) Name() string { return "NNS" }
+
+func (nnsGovernance) ListProposals() ([]Proposal, error) { return fetchProposals() }
+
+func (nnsGovernance) GetProposal(id uint64) (Proposal, error) {
+	details, err := fetchProposal(id)
+	return details.toProposal(), err
+}
+
+func (nnsGovernance) Topics() ([]string, error) { return NNS_TOPICS, nil }
+
+// Collects the distinct topics of `proposals`, for governance systems without a fixed list.
+func topicsOf(proposals []Proposal) []string {
+	topics := map[string]bool{}
+	for _, p := range proposals {
+		topics[p.Topic] = true
+	}
+	var res []string
+	for topic := range topics {
+		res = append(res, topic)
+	}
+	sort.Strings(res)
+	return res
+}
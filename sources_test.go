@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestOpenGovernance(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		want      Governance
+	}{
+		{"sns", "zxeu2-7aaaa-aaaaq-aaafa-cai", snsGovernance{"zxeu2-7aaaa-aaaaq-aaafa-cai", "OpenChat"}},
+		{"unknown", "cosmos-hub", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := openGovernance(tt.namespace, "OpenChat")
+			if ok != (tt.want != nil) || ok && got != tt.want {
+				t.Errorf("openGovernance(%q) = %v, %v, want %v", tt.namespace, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentVersionOfUnknownSystem(t *testing.T) {
+	if _, err := currentVersionOf(Proposal{Id: 7, Source: "cosmos-hub"}); err == nil {
+		t.Error("currentVersionOf() of a proposal of an unknown system returned no error")
+	}
+}
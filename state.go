@@ -0,0 +1,755 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Per-chat configuration.
+type Chat struct {
+	BlockedTopics map[string]bool `json:"blocked_topics"`
+	// In the FILTER_ONLY mode, only proposals with one of the OnlyTopics are delivered;
+	// otherwise all proposals except the ones with blocked topics.
+	FilterMode string          `json:"filter_mode,omitempty"`
+	OnlyTopics map[string]bool `json:"only_topics,omitempty"`
+	// Proposals have to contain one of the Keywords, if any, and none of the BlockedKeywords.
+	Keywords        []string `json:"keywords,omitempty"`
+	BlockedKeywords []string `json:"blocked_keywords,omitempty"`
+	// Neurons whose proposals are delivered regardless of the filters.
+	FollowedProposers map[uint64]bool `json:"followed_proposers,omitempty"`
+	BlockedProposers  map[uint64]bool `json:"blocked_proposers,omitempty"`
+	KnownNeuronVotes  bool            `json:"known_neuron_votes,omitempty"`
+	// Broadcast list of the operator whose filters this chat has, if any.
+	BroadcastList string `json:"broadcast_list,omitempty"`
+	// Whether the chat is notified when the NNS introduces a new topic.
+	TopicAlerts bool `json:"topic_alerts,omitempty"`
+	// Whether the bot doesn't reply to links to proposals with a preview.
+	NoPreviews bool `json:"no_previews,omitempty"`
+	// Whether the notifications start with a TL;DR written by the LLM at TLDR_API_URL.
+	TLDR          bool   `json:"tldr,omitempty"`
+	SummaryLength int    `json:"summary_length,omitempty"`
+	Format        string `json:"format,omitempty"`
+	// Time zone of the rendered times, e.g. Europe/Zurich; UTC if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// Language of the bot messages; English if empty.
+	Language string `json:"language,omitempty"`
+	// Layout of the notifications with placeholders like {title}; overrides the format.
+	Template string `json:"template,omitempty"`
+	// Footer appended to the notifications instead of FOOTER, and whether no footer is shown at all.
+	Footer     string          `json:"footer,omitempty"`
+	HideFooter bool            `json:"hide_footer,omitempty"`
+	Window     *DeliveryWindow `json:"window,omitempty"`
+	Quiet      *QuietHours     `json:"quiet,omitempty"`
+	// Proposals which arrived outside of the delivery window.
+	Deferred []Proposal `json:"deferred,omitempty"`
+	// Pinned message showing the settings in group chats and its last rendered text.
+	SettingsMessageId int    `json:"settings_message_id,omitempty"`
+	SettingsText      string `json:"settings_text,omitempty"`
+	// Time at which the bot lost the permission to post in this chat.
+	MutedSince *time.Time  `json:"muted_since,omitempty"`
+	Paused     bool        `json:"paused,omitempty"`
+	Deliveries []*Delivery `json:"deliveries,omitempty"`
+	Telemetry  bool        `json:"telemetry,omitempty"`
+	// Time at which the chat first reported that the bot was blocked and the number of
+	// unsuccessful delivery attempts since then.
+	UnreachableSince *time.Time `json:"unreachable_since,omitempty"`
+	FailedAttempts   int        `json:"failed_attempts,omitempty"`
+	DowntimeNotices  bool       `json:"downtime_notices,omitempty"`
+	// Neuron checked for missed voting rewards and the time of the last reminder.
+	RewardNeuron   uint64     `json:"reward_neuron,omitempty"`
+	RewardReminded *time.Time `json:"reward_reminded,omitempty"`
+	ImportantOnly  bool       `json:"important_only,omitempty"`
+	// Open proposals the members of a group are watching.
+	Watchlist []uint64 `json:"watchlist,omitempty"`
+	AutoPin   bool     `json:"auto_pin,omitempty"`
+	// Names of the subscribed governance systems other than the NNS by their namespace.
+	Namespaces map[string]string `json:"namespaces,omitempty"`
+	// Delivery mode, the proposals buffered for the next digest and the number of proposals
+	// dropped from the buffer at its limit.
+	DeliveryMode   string     `json:"delivery_mode,omitempty"`
+	DigestBuffer   []Proposal `json:"digest_buffer,omitempty"`
+	DigestOverflow int        `json:"digest_overflow,omitempty"`
+	// Proposals collected during the cooldowns of TOPIC_COOLDOWNS by topic.
+	Bursts      map[string]*Burst `json:"bursts,omitempty"`
+	WeeklyRecap bool              `json:"weekly_recap,omitempty"`
+	// Reply with the outcome once a proposal the chat was notified about is decided.
+	DecisionNotices bool `json:"decision_notices,omitempty"`
+	// Keep the notifications about open proposals updated with the current tally.
+	LiveTally bool `json:"live_tally,omitempty"`
+	// Keywords shown in bold in the summaries.
+	Highlights []string `json:"highlights,omitempty"`
+	// Ask monthly whether the notifications are useful.
+	FeedbackPoll bool `json:"feedback_poll,omitempty"`
+	// SHA-256 hash of the token granting access to this chat through the REST API.
+	APITokenHash string `json:"api_token_hash,omitempty"`
+	// User name of the bot the user talks to in a private chat; users can only be messaged by
+	// the bots they started.
+	Bot string `json:"bot,omitempty"`
+}
+
+// Returns true if notifications can be delivered to this chat at time `t`; otherwise they
+// should be deferred.
+func (c *Chat) deliverable(t time.Time) bool {
+	return !c.Paused && c.MutedSince == nil && c.UnreachableSince == nil && (c.Window == nil || c.Window.contains(t)) &&
+		(c.Quiet == nil || !c.Quiet.contains(t))
+}
+
+// Returns the number of characters after which summaries are truncated for this chat.
+func (c *Chat) summaryLength() int {
+	if c.SummaryLength == 0 {
+		return DEFAULT_SUMMARY_LENGTH
+	}
+	return c.SummaryLength
+}
+
+type State struct {
+	LastSeenProposal uint64 `json:"last_seen_proposal"`
+	// Newest proposal seen per namespace of the governance systems other than the NNS.
+	LastSeenProposals map[string]uint64    `json:"last_seen_proposals"`
+	LastFetch         time.Time            `json:"last_fetch"`
+	ChatIds           map[int64]*Chat      `json:"chats"`
+	Tracked           map[uint64]*Proposal `json:"tracked"`
+	// Tracked proposals of the other governance systems by proposalKey.
+	TrackedSNS map[string]*Proposal `json:"tracked_sns,omitempty"`
+	Activity   []*Activity          `json:"activity"`
+	Transfers  map[string]*Transfer `json:"transfers"`
+	// Configurations of recently removed chats.
+	Tombstones map[int64]*Tombstone `json:"tombstones"`
+	// Rolling cache of the most recent proposals, sorted by id.
+	Recent []Proposal `json:"recent"`
+	// New proposals whose fan-out is delayed by TOPIC_DELAYS.
+	Held []HeldProposal `json:"held"`
+	// Next runs of the scheduled jobs by name.
+	Jobs map[string]time.Time `json:"jobs"`
+	// Sentiment of the subscribers on the cached proposals by user id.
+	Sentiment map[uint64]map[int64]string `json:"sentiment"`
+	// Latest answer of each chat to the feedback poll.
+	Feedback map[int64]string `json:"feedback"`
+	// Attestations of community verifiers by proposal id.
+	Attestations map[uint64][]Attestation `json:"attestations"`
+	// Number of discovered proposals by topic, since the bot started to count them.
+	Topics map[string]int `json:"topics"`
+	// Times at which topics appeared after the registry was started.
+	TopicsFirstSeen map[string]time.Time `json:"topics_first_seen"`
+	// Lists of chats managed by the operator by name.
+	BroadcastLists map[string]*BroadcastList `json:"broadcast_lists"`
+	// Offsets of the next Telegram updates by bot user name.
+	UpdateOffsets map[string]int `json:"update_offsets"`
+	// Time of the last persistence, used to detect downtimes.
+	Heartbeat time.Time `json:"heartbeat"`
+	// Before chats had a configuration, only the blacklist was stored for every chat id.
+	LegacyChatIds map[int64]map[string]bool `json:"chat_ids,omitempty"`
+	// Command usage of the chats which opted into telemetry since the last report.
+	commandUsage map[string]int
+	// Time of the last help message sent to each chat.
+	lastHelp map[int64]time.Time
+	// Time of the last full summary sent in reply to each notification.
+	lastSummary map[messageRef]time.Time
+	// Chats with a catch-up in progress.
+	catchingUp map[int64]bool
+	lock       sync.RWMutex
+}
+
+// Locks the state, persists it to a temporary file, then moves the temporary
+// file to the location of the persisted state. This should avoid broken state
+// if the process gets killed in the middle of writing.
+func (s *State) persist() {
+	s.lock.Lock()
+	s.Heartbeat = time.Now()
+	data, err := json.Marshal(s)
+	s.lock.Unlock()
+	if err != nil {
+		log.Println("Couldn't serialize state:", err)
+		return
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(STATE_PATH), filepath.Base(STATE_PATH)+"_tmp_")
+	if err != nil {
+		log.Println("Couldn't create a temporary state file next to", STATE_PATH, ":", err)
+		return
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), STATE_PATH)
+	}
+	if err != nil {
+		log.Println("Couldn't write to state file", STATE_PATH, ":", err)
+		os.Remove(tmpFile.Name())
+		return
+	}
+	log.Println(len(data), "bytes persisted to", STATE_PATH)
+}
+
+// Deserialize the persisted state from the disk. Currently, prints an error on a first run.
+func (s *State) restore() {
+	data, err := os.ReadFile(STATE_PATH)
+	if err != nil {
+		log.Println("Couldn't read file", STATE_PATH)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Println("Couldn't deserialize the state file", STATE_PATH, ":", err)
+	}
+	if s.ChatIds == nil {
+		s.ChatIds = map[int64]*Chat{}
+	}
+	if s.Tracked == nil {
+		s.Tracked = map[uint64]*Proposal{}
+	}
+	if s.TrackedSNS == nil {
+		s.TrackedSNS = map[string]*Proposal{}
+	}
+	if s.Transfers == nil {
+		s.Transfers = map[string]*Transfer{}
+	}
+	if s.Tombstones == nil {
+		s.Tombstones = map[int64]*Tombstone{}
+	}
+	if s.Feedback == nil {
+		s.Feedback = map[int64]string{}
+	}
+	if s.BroadcastLists == nil {
+		s.BroadcastLists = map[string]*BroadcastList{}
+	}
+	if s.Topics == nil {
+		// Start the registry with the topics of the recent activity.
+		s.Topics = map[string]int{}
+		for _, a := range s.Activity {
+			s.Topics[a.Topic]++
+		}
+	}
+	if s.TopicsFirstSeen == nil {
+		s.TopicsFirstSeen = map[string]time.Time{}
+	}
+	if s.Attestations == nil {
+		s.Attestations = map[uint64][]Attestation{}
+	}
+	if s.Sentiment == nil {
+		s.Sentiment = map[uint64]map[int64]string{}
+	}
+	if s.UpdateOffsets == nil {
+		s.UpdateOffsets = map[string]int{}
+	}
+	if s.Jobs == nil {
+		s.Jobs = map[string]time.Time{}
+	}
+	if s.LastSeenProposals == nil {
+		s.LastSeenProposals = map[string]uint64{}
+	}
+	for id, blacklist := range s.LegacyChatIds {
+		if blacklist == nil {
+			blacklist = map[string]bool{}
+		}
+		s.ChatIds[id] = &Chat{BlockedTopics: blacklist}
+	}
+	s.LegacyChatIds = nil
+	// The governance only mode used to be stored as a special blocked topic.
+	for _, chat := range s.ChatIds {
+		if chat.BlockedTopics[ALL_EXCEPT_GOVERNANCE] {
+			delete(chat.BlockedTopics, ALL_EXCEPT_GOVERNANCE)
+			chat.FilterMode, chat.OnlyTopics = FILTER_ONLY, map[string]bool{TOPIC_GOVERNANCE: true}
+		}
+	}
+	fmt.Println("Deserialized the state with", len(s.ChatIds), "users, last proposal id:", s.LastSeenProposal)
+}
+
+// This is an atomic compare and swap for a new seen proposal id.
+func (s *State) setNewLastSeenId(id uint64) (updated bool) {
+	s.lock.Lock()
+	if s.LastSeenProposal < id {
+		s.LastSeenProposal = id
+		updated = true
+	}
+	s.lock.Unlock()
+	return
+}
+
+func (s *State) lastSeenProposal() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.LastSeenProposal
+}
+
+func (s *State) setLastFetch(t time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.LastFetch = t
+}
+
+// Returns the time since the last successful fetch of the proposals, or 0 on the first run.
+func (s *State) offline() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.LastFetch.IsZero() {
+		return 0
+	}
+	return time.Since(s.LastFetch)
+}
+
+// Unsubscribes the chat id; its configuration is kept as a tombstone.
+func (s *State) removeChatId(id int64, reason string) {
+	s.lock.Lock()
+	s.dropChat(id, reason)
+	s.lock.Unlock()
+	log.Println("Removed user", id, "from subscribers")
+}
+
+// Unsubscribes the chat id, keeps its configuration as a tombstone and takes it off its broadcast
+// list. Expects the lock to be held.
+func (s *State) dropChat(id int64, reason string) {
+	if chat := s.ChatIds[id]; chat != nil {
+		s.pruneTombstones()
+		s.Tombstones[id] = &Tombstone{Chat: chat, Removed: time.Now(), Reason: reason}
+		if list := s.BroadcastLists[chat.BroadcastList]; list != nil {
+			list.ChatIds = withoutChat(list.ChatIds, id)
+		}
+	}
+	delete(s.ChatIds, id)
+}
+
+// Moves the configuration of chat `id` to `newId` after a group was upgraded to a supergroup.
+func (s *State) migrateChat(id, newId int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		s.ChatIds[newId] = chat
+		delete(s.ChatIds, id)
+		if list := s.BroadcastLists[chat.BroadcastList]; list != nil {
+			list.ChatIds = append(withoutChat(list.ChatIds, id), newId)
+		}
+		log.Println("Migrated chat", id, "to", newId)
+	}
+}
+
+// Subscribes the chat id with the configuration `chat`.
+func (s *State) addChatId(id int64, chat *Chat) {
+	s.lock.Lock()
+	s.ChatIds[id] = chat
+	s.lock.Unlock()
+	log.Println("Added user", id, "to subscribers")
+}
+
+// Block `topic` for chat `id`. Checks max topic length and max blocked topics to avoid
+// trivial bloat attacks.
+func (s *State) blockTopic(id int64, topic string) {
+	if len(topic) > MAX_TOPIC_LENGTH {
+		return
+	}
+	s.lock.Lock()
+	chat := s.ChatIds[id]
+	if chat != nil && len(chat.BlockedTopics) < MAX_BLOCKED_TOPICS {
+		chat.BlockedTopics[topic] = true
+	}
+	s.lock.Unlock()
+}
+
+// Switches chat `id` to only receive proposals with one of `topics`; nil switches back to
+// blocking topics.
+func (s *State) setOnlyTopics(id int64, topics []string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	if topics == nil {
+		chat.FilterMode, chat.OnlyTopics = "", nil
+		return true
+	}
+	chat.FilterMode, chat.OnlyTopics = FILTER_ONLY, map[string]bool{}
+	for _, topic := range topics {
+		chat.OnlyTopics[topic] = true
+	}
+	return true
+}
+
+// Unblocks `topic` for chat `id`.
+func (s *State) unblockTopic(id int64, topic string) {
+	s.lock.Lock()
+	chat := s.ChatIds[id]
+	if chat != nil {
+		delete(chat.BlockedTopics, topic)
+	}
+	s.lock.Unlock()
+}
+
+// Enables or disables the known neuron vote breakdowns for chat `id`.
+func (s *State) setKnownNeuronVotes(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.KnownNeuronVotes = enabled
+	return true
+}
+
+// Sets the summary length for chat `id`.
+func (s *State) setSummaryLength(id int64, length int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.SummaryLength = length
+	return true
+}
+
+// Sets the notification format for chat `id`.
+func (s *State) setFormat(id int64, format string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Format = format
+	return true
+}
+
+// Sets the delivery window for chat `id`; nil removes the window.
+func (s *State) setDeliveryWindow(id int64, window *DeliveryWindow) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Window = window
+	return true
+}
+
+func (s *State) setQuietHours(id int64, quiet *QuietHours) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Quiet = quiet
+	return true
+}
+
+// Stores the pinned settings message of chat `id`; a zero message id removes it.
+func (s *State) setPinnedSettings(id int64, messageId int, text string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.SettingsMessageId = messageId
+		chat.SettingsText = text
+	}
+}
+
+// Stores `proposal` until the delivery window of chat `id` opens. Only the most recent
+// MAX_DEFERRED_PROPOSALS proposals are kept, without their summaries.
+func (s *State) deferProposal(id int64, proposal Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return
+	}
+	proposal.Summary = ""
+	chat.Deferred = append(chat.Deferred, proposal)
+	if len(chat.Deferred) > MAX_DEFERRED_PROPOSALS {
+		chat.Deferred = chat.Deferred[len(chat.Deferred)-MAX_DEFERRED_PROPOSALS:]
+	}
+}
+
+// Removes and returns the deferred proposals of all chats whose delivery window is open at `t`.
+func (s *State) takeDeferred(t time.Time) map[int64][]Proposal {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := map[int64][]Proposal{}
+	for id, chat := range s.ChatIds {
+		if len(chat.Deferred) > 0 && chat.deliverable(t) {
+			res[id] = chat.Deferred
+			chat.Deferred = nil
+		}
+	}
+	return res
+}
+
+// Pauses or resumes the deliveries to chat `id` on request. Unless `catchUp` is set, the
+// proposals deferred while paused are discarded on resume. Returns the number of deferred
+// proposals.
+func (s *State) setPaused(id int64, paused, catchUp bool) (int, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return 0, false
+	}
+	chat.Paused = paused
+	if !paused && !catchUp {
+		chat.Deferred = nil
+	}
+	return len(chat.Deferred), true
+}
+
+// Pauses or resumes the deliveries to chat `id` after the bot lost or regained the permission
+// to post there.
+func (s *State) setMuted(id int64, muted bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil || muted == (chat.MutedSince != nil) {
+		return
+	}
+	if muted {
+		now := time.Now()
+		chat.MutedSince = &now
+		log.Println("Paused deliveries to", id, "after losing the permission to post")
+	} else {
+		chat.MutedSince = nil
+		log.Println("Resumed deliveries to", id)
+	}
+}
+
+// Records a failed delivery to chat `id` because the bot was blocked. The chat is only removed
+// once it stayed unreachable for UNREACHABLE_GRACE and MIN_FAILED_ATTEMPTS attempts, as
+// Telegram occasionally misreports transient errors as blocks.
+func (s *State) markUnreachable(id int64) {
+	s.lock.Lock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		s.lock.Unlock()
+		return
+	}
+	now := time.Now()
+	if chat.UnreachableSince == nil {
+		chat.UnreachableSince = &now
+		log.Println("Chat", id, "became unreachable")
+	}
+	chat.FailedAttempts++
+	remove := now.Sub(*chat.UnreachableSince) >= UNREACHABLE_GRACE && chat.FailedAttempts >= MIN_FAILED_ATTEMPTS
+	s.lock.Unlock()
+	if remove {
+		s.removeChatId(id, "unreachable")
+	}
+}
+
+// Marks chat `id` as reachable again.
+func (s *State) markReachable(id int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil && chat.UnreachableSince != nil {
+		chat.UnreachableSince = nil
+		chat.FailedAttempts = 0
+		log.Println("Chat", id, "is reachable again")
+	}
+}
+
+// Returns the ids of all chats which reported that the bot was blocked.
+func (s *State) unreachableChatIds() (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if chat.UnreachableSince != nil {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Returns the ids of all chats where the bot lost the permission to post.
+func (s *State) mutedChatIds() (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if chat.MutedSince != nil {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Returns a copy of the configuration of chat `id`.
+func (s *State) chat(id int64) (Chat, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return Chat{}, false
+	}
+	return *chat, true
+}
+
+// Returns true if `chat` should be notified about `topic`.
+func acceptsTopic(chat *Chat, topic string) bool {
+	if chat == nil {
+		return false
+	}
+	if chat.FilterMode == FILTER_ONLY {
+		return chat.OnlyTopics[topic]
+	}
+	return !chat.BlockedTopics[topic]
+}
+
+// Returns the list of chat ids which should be notified about `proposal`.
+func (s *State) chatIdsForProposal(proposal Proposal) (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if acceptsProposal(chat, proposal) {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Returns the list of chat ids which opted into known neuron vote breakdowns and accept `proposal`.
+func (s *State) chatIdsForVoteBreakdown(proposal Proposal) (res []int64) {
+	s.lock.RLock()
+	for id, chat := range s.ChatIds {
+		if chat.KnownNeuronVotes && acceptsProposal(chat, proposal) {
+			res = append(res, id)
+		}
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Starts tracking the status of an announced proposal until it gets decided.
+func (s *State) track(proposal Proposal) {
+	s.lock.Lock()
+	if proposal.Source != "" {
+		s.TrackedSNS[proposalKey(proposal)] = &proposal
+	} else {
+		s.Tracked[proposal.Id] = &proposal
+	}
+	s.lock.Unlock()
+}
+
+func (s *State) untrackSNS(proposal Proposal) {
+	s.lock.Lock()
+	delete(s.TrackedSNS, proposalKey(proposal))
+	s.lock.Unlock()
+}
+
+// Returns copies of the tracked proposals of the other governance systems.
+func (s *State) trackedSNSProposals() (res []Proposal) {
+	s.lock.RLock()
+	for _, p := range s.TrackedSNS {
+		res = append(res, *p)
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Stops tracking the proposal `id`.
+func (s *State) untrack(id uint64) {
+	s.lock.Lock()
+	delete(s.Tracked, id)
+	s.lock.Unlock()
+}
+
+// Returns a copy of the tracked proposal `id`.
+func (s *State) trackedProposal(id uint64) (Proposal, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if p := s.Tracked[id]; p != nil {
+		return *p, true
+	}
+	return Proposal{}, false
+}
+
+// Returns copies of all tracked proposals.
+func (s *State) trackedProposals() (res []Proposal) {
+	s.lock.RLock()
+	for _, p := range s.Tracked {
+		res = append(res, *p)
+	}
+	s.lock.RUnlock()
+	return
+}
+
+// Returns a string of blocked topics.
+func (s *State) blockedTopics(id int64) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	chat := s.ChatIds[id]
+	if chat != nil && chat.FilterMode == FILTER_ONLY {
+		return "You only receive proposals with these topics: " + strings.Join(sortedKeys(chat.OnlyTopics), ", ") + "."
+	}
+	if chat == nil || len(chat.BlockedTopics) == 0 {
+		return "Your list of blocked topics is empty."
+	}
+	var res []string
+	for topic, enabled := range chat.BlockedTopics {
+		if enabled {
+			res = append(res, topic)
+		}
+	}
+	return fmt.Sprintf("You've blocked these topics: %s.", strings.Join(res, ", "))
+}
+
+func sortedKeys(set map[string]bool) (res []string) {
+	for key, enabled := range set {
+		if enabled {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return
+}
+
+// Returns the tracked proposals which are still open for voting and whose deadline is known.
+func (s *State) openProposals() (res []Proposal) {
+	for _, p := range s.trackedProposals() {
+		if p.Status == STATUS_OPEN && p.Deadline > time.Now().Unix() {
+			res = append(res, p)
+		}
+	}
+	return
+}
+
+// Returns the open proposals matching the filters of chat `id`, sorted by voting deadline.
+func (s *State) deadlines(id int64, proposals []Proposal) string {
+	chat, _ := s.chat(id)
+	s.lock.RLock()
+	var res []Proposal
+	for _, p := range proposals {
+		if acceptsProposal(s.ChatIds[id], p) {
+			res = append(res, p)
+		}
+	}
+	s.lock.RUnlock()
+	if len(res) == 0 {
+		return "There are no open proposals matching your filters."
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Deadline < res[j].Deadline })
+	lines := []string{"Open proposals by voting deadline:"}
+	for _, p := range res {
+		deadline := time.Unix(p.Deadline, 0)
+		lines = append(lines, fmt.Sprintf("⏳ %s (%s): %s (%s)\n%s",
+			formatCountdown(time.Until(deadline)), formatTime(deadline, chat), shortTitle(p.Title), hashtags(p), proposalURL(p.Id)))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// Formats the remaining time as a short countdown, e.g. "2d 5h" or "3h 12m".
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "closing now"
+	}
+	days, hours, minutes := int(d.Hours())/24, int(d.Hours())%24, int(d.Minutes())%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	defer func(path string) { STATE_PATH = path }(STATE_PATH)
+	STATE_PATH = filepath.Join(dir, "state.json")
+	state := State{ChatIds: map[int64]*Chat{42: {}}}
+	state.persist()
+	var restored State
+	restored.restore()
+	if _, ok := restored.ChatIds[42]; !ok {
+		t.Fatalf("the persisted state in %s wasn't restored", STATE_PATH)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the state file in %s, found %d files", dir, len(files))
+	}
+}
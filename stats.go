@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record of an announced proposal kept for governance statistics.
+type Activity struct {
+	Id       uint64    `json:"id"`
+	Title    string    `json:"title"`
+	Topic    string    `json:"topic"`
+	Proposer uint64    `json:"proposer"`
+	Time     time.Time `json:"time"`
+	// Final status, set once the proposal is decided.
+	Status string `json:"status,omitempty"`
+	// Reactions of the subscribers to the notifications about the proposal.
+	Reactions int `json:"reactions,omitempty"`
+	// Names of known neurons mapped to whether they voted; set once the proposal is decided.
+	Ballots map[string]bool `json:"ballots,omitempty"`
+}
+
+// Records an announced proposal and drops all records older than STATS_WINDOW.
+func (s *State) recordActivity(proposal Proposal) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cutoff := time.Now().Add(-STATS_WINDOW)
+	var res []*Activity
+	for _, a := range s.Activity {
+		if a.Time.After(cutoff) {
+			res = append(res, a)
+		}
+	}
+	s.Activity = append(res, &Activity{Id: proposal.Id, Title: proposal.Title, Topic: proposal.Topic, Proposer: proposal.Proposer, Time: time.Now()})
+}
+
+// Records the participation of known neurons in the decided proposal `id`.
+func (s *State) recordBallots(id uint64, ballots []ballot) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, a := range s.Activity {
+		if a.Id != id {
+			continue
+		}
+		a.Ballots = map[string]bool{}
+		for _, b := range ballots {
+			a.Ballots[b.Name] = b.Vote == VOTE_YES || b.Vote == VOTE_NO
+		}
+	}
+}
+
+type rank struct {
+	name  string
+	value float64
+}
+
+// Sorts ranks by value in descending order and keeps the first LEADERBOARD_SIZE entries.
+func topRanks(ranks []rank) []rank {
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].value == ranks[j].value {
+			return ranks[i].name < ranks[j].name
+		}
+		return ranks[i].value > ranks[j].value
+	})
+	if len(ranks) > LEADERBOARD_SIZE {
+		ranks = ranks[:LEADERBOARD_SIZE]
+	}
+	return ranks
+}
+
+// Returns the most active proposers and the known neurons with the highest voting
+// participation within the statistics window.
+func (s *State) leaderboard() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	proposals := map[uint64]int{}
+	votes := map[string]int{}
+	decided := 0
+	for _, a := range s.Activity {
+		proposals[a.Proposer]++
+		if a.Ballots == nil {
+			continue
+		}
+		decided++
+		for name, voted := range a.Ballots {
+			if voted {
+				votes[name]++
+			}
+		}
+	}
+	if len(proposals) == 0 {
+		return "No proposals were recorded yet."
+	}
+	var proposers, neurons []rank
+	for proposer, count := range proposals {
+		label := fmt.Sprintf("Neuron %d", proposer)
+		if knownNeurons.name(proposer) != "" {
+			label = proposerName(proposer)
+		}
+		proposers = append(proposers, rank{label, float64(count)})
+	}
+	for name, count := range votes {
+		neurons = append(neurons, rank{name, 100 * float64(count) / float64(decided)})
+	}
+	days := int(STATS_WINDOW.Hours() / 24)
+	lines := []string{fmt.Sprintf("Most active proposers (last %d days):", days)}
+	for i, r := range topRanks(proposers) {
+		lines = append(lines, fmt.Sprintf("%d. %s: %.0f proposals", i+1, r.name, r.value))
+	}
+	if decided > 0 {
+		lines = append(lines, "", fmt.Sprintf("Known neuron participation (%d decided proposals):", decided))
+		for i, r := range topRanks(neurons) {
+			lines = append(lines, fmt.Sprintf("%d. %s: %.0f%%", i+1, r.name, r.value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
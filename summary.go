@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	CALLBACK_FULL_SUMMARY = "summary:"
+	// Minimal interval between two full summaries sent in reply to the same notification.
+	SUMMARY_THROTTLE_INTERVAL = 10 * time.Minute
+)
+
+// Message `message` in chat `chat`.
+type messageRef struct {
+	chat    int64
+	message int
+}
+
+// Returns the button sending the full summary of `proposal` if the notification in `chat` shows
+// only a part of it. SNS proposal ids overlap with NNS ones, so they get no button.
+func fullSummaryButton(proposal Proposal, chat Chat) (tgbotapi.InlineKeyboardButton, bool) {
+	summary := []rune(sanitizeSummary(proposal.Summary))
+	shortened := len(summary) > chat.summaryBudget() || chat.Format == FORMAT_COMPACT && chat.Template == ""
+	if proposal.Source != "" || len(summary) == 0 || !shortened {
+		return tgbotapi.InlineKeyboardButton{}, false
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("📖 Show full summary", fmt.Sprintf("%s%d", CALLBACK_FULL_SUMMARY, proposal.Id)), true
+}
+
+// Sends the full summary of the proposal as a reply to the notification, split into several
+// messages if needed. Returns the answer to the callback query.
+func handleFullSummary(shards *Shards, state *State, query *tgbotapi.CallbackQuery) string {
+	proposalId, err := strconv.ParseUint(strings.TrimPrefix(query.Data, CALLBACK_FULL_SUMMARY), 10, 64)
+	if err != nil {
+		return ""
+	}
+	proposal, err := state.findProposal(proposalId)
+	if err != nil {
+		return fmt.Sprintf("Couldn't find proposal %d.", proposalId)
+	}
+	id := query.Message.Chat.ID
+	if !state.allowFullSummary(id, query.Message.MessageID, time.Now()) {
+		return "The full summary was just sent, see the reply to this notification."
+	}
+	chat, _ := state.chat(id)
+	text := fmt.Sprintf("<b>Summary of proposal %d</b>\n\n%s", proposal.Id, markdownToHTML(sanitizeSummary(proposal.Summary), chat.Highlights))
+	msg := tgbotapi.NewMessage(id, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.DisableWebPagePreview = true
+	msg.ReplyToMessageID = query.Message.MessageID
+	msg.AllowSendingWithoutReply = true
+	if _, err := send(shards, state, msg); err != nil {
+		return "Couldn't send the summary, please try again later."
+	}
+	return ""
+}
+
+// Returns true and records the time if no full summary was sent in reply to message `messageId`
+// in chat `id` within the last SUMMARY_THROTTLE_INTERVAL before `now`.
+func (s *State) allowFullSummary(id int64, messageId int, now time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ref := messageRef{id, messageId}
+	if last, ok := s.lastSummary[ref]; ok && now.Sub(last) < SUMMARY_THROTTLE_INTERVAL {
+		return false
+	}
+	if s.lastSummary == nil {
+		s.lastSummary = map[messageRef]time.Time{}
+	}
+	for r, last := range s.lastSummary {
+		if now.Sub(last) >= SUMMARY_THROTTLE_INTERVAL {
+			delete(s.lastSummary, r)
+		}
+	}
+	s.lastSummary[ref] = now
+	return true
+}
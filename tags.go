@@ -0,0 +1,66 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Keyword rules classifying governance proposals (mostly motions) into finer sub-tags, which can
+// be blocked like topics. A proposal gets every tag with a keyword in its title or summary.
+var governanceTagRules = []struct {
+	Tag     string
+	Pattern *regexp.Regexp
+}{
+	{"Tokenomics", regexp.MustCompile(`(?i)\b(tokenomics|inflation|deflation|voting rewards?|maturity|minting|burn(ing)?|icp supply|dissolve delay|staking)\b`)},
+	{"NodeProviderPolicy", regexp.MustCompile(`(?i)\b(node providers?|node rewards?|node machines?|data cent(er|re)s?|remuneration)\b`)},
+	{"SNS", regexp.MustCompile(`(?i)\b(sns|service nervous system|decentralization swap)\b`)},
+	{"ProtocolRoadmap", regexp.MustCompile(`(?i)\b(roadmap|milestones?|protocol (upgrade|change|improvement)s?|feature request)\b`)},
+}
+
+// Returns the sub-tags of a governance proposal; other topics have none.
+func governanceTags(proposal Proposal) (res []string) {
+	if proposal.Topic != TOPIC_GOVERNANCE {
+		return nil
+	}
+	for _, rule := range governanceTagRules {
+		if rule.Pattern.MatchString(proposal.Title) || rule.Pattern.MatchString(proposal.Summary) {
+			res = append(res, rule.Tag)
+		}
+	}
+	return
+}
+
+// Returns the topic and the sub-tags of the proposal as hashtags, e.g. `#Governance #SNS`.
+func hashtags(proposal Proposal) string {
+	tags := []string{"#" + proposal.Topic}
+	for _, tag := range governanceTags(proposal) {
+		tags = append(tags, "#"+tag)
+	}
+	return strings.Join(tags, " ")
+}
+
+// Returns true if `chat` should be notified about `proposal`, considering the topic and proposer
+// filters, the sub-tags, the keyword filters and the important only mode. Proposals of followed proposers
+// bypass all filters.
+func acceptsProposal(chat *Chat, proposal Proposal) bool {
+	if chat != nil && chat.FollowedProposers[proposal.Proposer] {
+		return true
+	}
+	if !acceptsTopic(chat, proposal.Topic) || chat.BlockedProposers[proposal.Proposer] {
+		return false
+	}
+	for _, tag := range governanceTags(proposal) {
+		if chat.BlockedTopics[tag] {
+			return false
+		}
+	}
+	return acceptsKeywords(chat, proposal) && important(chat, proposal)
+}
+
+func tagsCommand(r *Request) string {
+	lines := []string{"Governance proposals are tagged by keywords in their title and summary:"}
+	for _, rule := range governanceTagRules {
+		lines = append(lines, "#"+rule.Tag)
+	}
+	return strings.Join(lines, "\n") + "\n\nUse /block <tag> to skip the governance proposals with a tag, e.g. /block #SNS."
+}
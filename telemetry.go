@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Anonymized, aggregate usage counters reported to TELEMETRY_URL. Only chats which opted in
+// with /telemetry contribute command counts; no chat ids or message contents are reported.
+type TelemetryReport struct {
+	Subscribers  int            `json:"subscribers"`
+	Participants int            `json:"participants"`
+	Commands     map[string]int `json:"commands"`
+	Period       string         `json:"period"`
+}
+
+// Counts the usage of a recognized command in chat `id` if the chat opted into telemetry.
+func (s *State) countCommand(id int64, cmd string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil && chat.Telemetry {
+		if s.commandUsage == nil {
+			s.commandUsage = map[string]int{}
+		}
+		s.commandUsage[cmd]++
+	}
+}
+
+// Enables or disables the participation of chat `id` in telemetry.
+func (s *State) setTelemetry(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Telemetry = enabled
+	return true
+}
+
+// Returns the report for the past period and resets the command counters.
+func (s *State) takeTelemetryReport() TelemetryReport {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	report := TelemetryReport{Subscribers: len(s.ChatIds), Commands: s.commandUsage, Period: TELEMETRY_INTERVAL.String()}
+	for _, chat := range s.ChatIds {
+		if chat.Telemetry {
+			report.Participants++
+		}
+	}
+	s.commandUsage = nil
+	return report
+}
+
+// Posts the telemetry report to TELEMETRY_URL; scheduled every TELEMETRY_INTERVAL.
+func sendTelemetryReport(state *State) {
+	report := state.takeTelemetryReport()
+	if report.Participants == 0 {
+		return
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Println("Couldn't serialize the telemetry report:", err)
+		return
+	}
+	resp, err := apiClient.Post(TELEMETRY_URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Println("Couldn't send the telemetry report:", err)
+		return
+	}
+	resp.Body.Close()
+	log.Println("Sent the telemetry report of", report.Participants, "participating chats")
+}
+
+func telemetryDescription(enabled bool) string {
+	if TELEMETRY_URL == "" {
+		return "Telemetry is disabled for this deployment of the bot."
+	}
+	status := "not participating"
+	if enabled {
+		status = "participating"
+	}
+	return fmt.Sprintf("This chat is %s in telemetry. If enabled, the bot reports how often each command is used "+
+		"and the total number of subscribers, aggregated over all participating chats once every %s. "+
+		"No chat ids or messages are reported. Use /telemetry on or /telemetry off to change it.",
+		status, TELEMETRY_INTERVAL)
+}
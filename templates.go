@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const MAX_TEMPLATE_LENGTH = 1000
+
+var templatePlaceholderPattern = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// Values of the placeholders of `proposal`, rendered for the HTML parse mode.
+func templateValues(proposal Proposal, chat Chat) map[string]string {
+	summary, _ := truncateAtWord(sanitizeSummary(proposal.Summary), chat.summaryBudget())
+	tally := ""
+	if proposal.Tally != nil {
+		tally = formatTally(*proposal.Tally)
+	}
+	return map[string]string{
+		"{id}":       fmt.Sprint(proposal.Id),
+		"{title}":    html.EscapeString(proposal.Title),
+		"{topic}":    html.EscapeString(proposal.Topic),
+		"{hashtags}": hashtags(proposal),
+		"{proposer}": html.EscapeString(proposerName(proposal.Proposer)),
+		"{status}":   statusBadge(proposal),
+		"{summary}":  markdownToHTML(summary, chat.Highlights),
+		"{tally}":    tally,
+		"{link}":     htmlURL(proposal),
+	}
+}
+
+// Renders `proposal` with the template of `chat`. The template itself is plain text.
+func renderTemplate(proposal Proposal, chat Chat) string {
+	return fillTemplate(chat.Template, proposal, chat)
+}
+
+// Substitutes the placeholders in the plain text `template` with the values of `proposal`.
+func fillTemplate(template string, proposal Proposal, chat Chat) string {
+	values := templateValues(proposal, chat)
+	return templatePlaceholderPattern.ReplaceAllStringFunc(html.EscapeString(template), func(placeholder string) string {
+		if value, ok := values[placeholder]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+func templatePlaceholders() string {
+	var res []string
+	for placeholder := range templateValues(Proposal{}, Chat{}) {
+		res = append(res, placeholder)
+	}
+	sort.Strings(res)
+	return strings.Join(res, ", ")
+}
+
+// Returns an error if `template` is longer than `limit` characters or contains unknown placeholders.
+func validateTemplate(template string, limit int) error {
+	if len([]rune(template)) > limit {
+		return fmt.Errorf("it can have at most %d characters", limit)
+	}
+	values := templateValues(Proposal{}, Chat{})
+	for _, placeholder := range templatePlaceholderPattern.FindAllString(template, -1) {
+		if _, ok := values[placeholder]; !ok {
+			return fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+	}
+	return nil
+}
+
+func (s *State) setTemplate(id int64, template string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Template = template
+	return true
+}
+
+// Sets the template to the text after the command, which may span several lines.
+func templateCommand(r *Request) string {
+	if len(r.args) == 0 {
+		chat, _ := r.state.chat(r.id)
+		current := "This chat uses no template."
+		if chat.Template != "" {
+			current = "The template of this chat is:\n\n" + chat.Template
+		}
+		return fmt.Sprintf("%s\n\nUse /template followed by the layout of the notifications, e.g. "+
+			"/template {status} {title} ({topic}) {link}\nPlaceholders: %s\nUse /template off to go back to the default layout.",
+			current, templatePlaceholders())
+	}
+	template := ""
+	if len(r.args) != 1 || r.args[0] != "off" {
+		text := strings.TrimSpace(r.message.Text)
+		template = strings.TrimSpace(text[strings.IndexAny(text, " \n\t"):])
+	}
+	if err := validateTemplate(template, MAX_TEMPLATE_LENGTH); err != nil {
+		return fmt.Sprintf("Couldn't set the template: %v.", err)
+	}
+	if !r.state.setTemplate(r.id, template) {
+		return NOT_SUBSCRIBED
+	}
+	if template == "" {
+		return "The notifications will use the default layout again."
+	}
+	return "The notifications will use your template from now on."
+}
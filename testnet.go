@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// Whether the bot runs against a test deployment of the governance canisters, e.g. to rehearse a
+// release. The state and the history are then kept in separate files and all messages carry a banner.
+var TESTNET = false
+
+const TESTNET_BANNER = "🧪 TESTNET"
+
+// Moves the state and the history into the testnet namespace, e.g. state.json to state.testnet.json.
+func configureTestnet() {
+	if !TESTNET {
+		return
+	}
+	STATE_PATH, HISTORY_PATH = testnetPath(STATE_PATH), testnetPath(HISTORY_PATH)
+	log.Println("Running in testnet mode with the state in", STATE_PATH, "and the history in", HISTORY_PATH)
+	if URL == flag.Lookup("source-url").DefValue {
+		log.Println("SOURCE_URL is not set, the testnet bot reads the production proposal feed")
+	}
+	if GOVERNANCE_API_URL == flag.Lookup("governance-api-url").DefValue {
+		log.Println("GOVERNANCE_API_URL is not set, the testnet bot reads the production governance API")
+	}
+}
+
+func testnetPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".testnet" + ext
+}
+
+// Prepends the testnet banner to `text` in testnet mode.
+func withTestnetBanner(text string) string {
+	if !TESTNET || strings.HasPrefix(text, TESTNET_BANNER) {
+		return text
+	}
+	return TESTNET_BANNER + "\n\n" + text
+}
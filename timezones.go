@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	// Embeds the time zone database, so that the zones are available on any host.
+	_ "time/tzdata"
+)
+
+// Returns the time zone of `chat`, UTC by default.
+func (c Chat) location() *time.Location {
+	if c.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Formats `t` in the time zone of `chat`, e.g. "Fri, 3 May 14:00 CEST".
+func formatTime(t time.Time, chat Chat) string {
+	return t.In(chat.location()).Format("Mon, 2 Jan 15:04 MST")
+}
+
+// Returns the local time of the day at which the digests and recaps are sent, e.g. "10:00 CEST".
+func digestTime(chat Chat) string {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), DIGEST_HOUR, 0, 0, 0, time.UTC).In(chat.location()).Format("15:04 MST")
+}
+
+func (s *State) setTimezone(id int64, timezone string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.Timezone = timezone
+	return true
+}
+
+func timezoneCommand(r *Request) string {
+	if len(r.args) != 1 {
+		chat, _ := r.state.chat(r.id)
+		return fmt.Sprintf("Times are shown in %s. Please use /timezone followed by a zone of the tz database, "+
+			"e.g. /timezone Europe/Zurich, or /timezone off for UTC.", chat.location())
+	}
+	timezone := r.args[0]
+	if timezone == "off" || timezone == "UTC" {
+		timezone = ""
+	} else if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Sprintf("Unknown time zone %s; please use a zone of the tz database like Europe/Zurich.", timezone)
+	}
+	if !r.state.setTimezone(r.id, timezone) {
+		return NOT_SUBSCRIBED
+	}
+	chat, _ := r.state.chat(r.id)
+	return fmt.Sprintf("Times are shown in %s from now on; it's %s now.", chat.location(), formatTime(time.Now(), chat))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OpenAI-compatible endpoint and model writing the TL;DR of proposals; disabled without a key.
+var (
+	TLDR_API_URL     = "https://api.openai.com/v1"
+	TLDR_API_KEY     = os.Getenv("TLDR_API_KEY")
+	TLDR_MODEL       = "gpt-4o-mini"
+	MAX_CACHED_TLDRS = 200
+)
+
+const TLDR_PROMPT = "You summarize governance proposals of the Internet Computer for a general audience. " +
+	"Reply with two or three short sentences in plain language explaining what the proposal changes and why. " +
+	"Don't use markdown, lists or links."
+
+// Cache of the TL;DRs by proposal, so that each proposal is summarized only once for all chats.
+// Concurrent renders of a proposal share one request; failed requests aren't cached, so the next
+// render retries.
+type Summarizer struct {
+	tldrs map[string]string
+	order []string
+	// Requests in flight by proposal.
+	pending map[string]*tldrRequest
+	lock    sync.Mutex
+}
+
+// Request for a TL;DR; `tldr` is set before `done` is closed.
+type tldrRequest struct {
+	done chan struct{}
+	tldr string
+}
+
+var summarizer = Summarizer{tldrs: map[string]string{}, pending: map[string]*tldrRequest{}}
+
+// Returns the TL;DR of `proposal`, requesting it from the LLM endpoint if it isn't cached. The lock
+// isn't held during the request, so renders of other proposals aren't blocked.
+func (s *Summarizer) tldr(proposal Proposal) string {
+	if TLDR_API_KEY == "" || strings.TrimSpace(proposal.Summary) == "" {
+		return ""
+	}
+	key := proposalKey(proposal)
+	s.lock.Lock()
+	if tldr, ok := s.tldrs[key]; ok {
+		s.lock.Unlock()
+		return tldr
+	}
+	if req, ok := s.pending[key]; ok {
+		s.lock.Unlock()
+		<-req.done
+		return req.tldr
+	}
+	req := &tldrRequest{done: make(chan struct{})}
+	s.pending[key] = req
+	s.lock.Unlock()
+
+	tldr, err := requestTLDR(proposal)
+	if err != nil {
+		log.Println("Couldn't summarize proposal", proposal.Id, ":", err)
+	}
+	s.lock.Lock()
+	delete(s.pending, key)
+	if err == nil {
+		s.tldrs[key] = tldr
+		s.order = append(s.order, key)
+		if len(s.order) > MAX_CACHED_TLDRS {
+			delete(s.tldrs, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	s.lock.Unlock()
+	req.tldr = tldr
+	close(req.done)
+	return tldr
+}
+
+// Asks the chat completions API for the TL;DR of the title and summary of `proposal`.
+func requestTLDR(proposal Proposal) (string, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	data, err := json.Marshal(struct {
+		Model       string    `json:"model"`
+		Messages    []message `json:"messages"`
+		MaxTokens   int       `json:"max_tokens"`
+		Temperature float64   `json:"temperature"`
+	}{TLDR_MODEL, []message{
+		{"system", TLDR_PROMPT},
+		{"user", proposal.Title + "\n\n" + sanitizeSummary(proposal.Summary)},
+	}, 200, 0.2})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(TLDR_API_URL, "/")+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+TLDR_API_KEY)
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	var res struct {
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	if len(res.Choices) == 0 {
+		return "", fmt.Errorf("no choices in the response")
+	}
+	return strings.TrimSpace(res.Choices[0].Message.Content), nil
+}
+
+// Renders the TL;DR of `proposal` for chats which enabled it, or an empty string.
+func renderTLDR(proposal Proposal, chat Chat) string {
+	if !chat.TLDR {
+		return ""
+	}
+	if tldr := summarizer.tldr(proposal); tldr != "" {
+		return "💡 <b>TL;DR:</b> " + html.EscapeString(tldr)
+	}
+	return ""
+}
+
+func (s *State) setTLDR(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.TLDR = enabled
+	return true
+}
+
+func tldrCommand(r *Request) string {
+	if TLDR_API_KEY == "" {
+		return "TL;DR summaries are disabled for this deployment of the bot."
+	}
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /tldr on or /tldr off"
+	}
+	if !r.state.setTLDR(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "The notifications will start with a short, automatically generated TL;DR of the summary. " +
+			"It may be inaccurate, so please read the proposal before voting. Use /tldr off to undo."
+	}
+	return "The notifications won't contain a TL;DR anymore."
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSummarizerTLDR(t *testing.T) {
+	requests, fail := 0, true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if fail {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": " Upgrades the ledger. "}}]}`))
+	}))
+	defer server.Close()
+	defer func(url, key string) { TLDR_API_URL, TLDR_API_KEY = url, key }(TLDR_API_URL, TLDR_API_KEY)
+	TLDR_API_URL, TLDR_API_KEY = server.URL, "key"
+	s := Summarizer{tldrs: map[string]string{}, pending: map[string]*tldrRequest{}}
+	proposal := Proposal{Id: 1, Title: "Upgrade", Summary: "Upgrade the ledger canister."}
+	tests := []struct {
+		name         string
+		fail         bool
+		want         string
+		wantRequests int
+	}{
+		{"failure", true, "", 1},
+		{"failure isn't cached", true, "", 2},
+		{"success", false, "Upgrades the ledger.", 3},
+		{"success is cached", false, "Upgrades the ledger.", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fail = tt.fail
+			if tldr := s.tldr(proposal); tldr != tt.want || requests != tt.wantRequests {
+				t.Errorf("tldr() = %q after %d requests, want %q after %d", tldr, requests, tt.want, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSummarizerSharesPendingRequests(t *testing.T) {
+	defer func(key string) { TLDR_API_KEY = key }(TLDR_API_KEY)
+	TLDR_API_KEY = "key"
+	proposal := Proposal{Id: 2, Summary: "Upgrade the registry."}
+	req := &tldrRequest{done: make(chan struct{})}
+	s := Summarizer{tldrs: map[string]string{}, pending: map[string]*tldrRequest{proposalKey(proposal): req}}
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.tldr(proposal)
+		}(i)
+	}
+	req.tldr = "Upgrades the registry."
+	close(req.done)
+	wg.Wait()
+	for i, tldr := range results {
+		if tldr != req.tldr {
+			t.Errorf("render %d got %q, want the result of the pending request %q", i, tldr, req.tldr)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration of an unsubscribed chat, kept for TOMBSTONE_RETENTION so that an accidental
+// removal can be undone.
+type Tombstone struct {
+	Chat    *Chat     `json:"chat"`
+	Removed time.Time `json:"removed"`
+	Reason  string    `json:"reason"`
+}
+
+// Drops the tombstones older than TOMBSTONE_RETENTION. Expects the lock to be held.
+func (s *State) pruneTombstones() {
+	for id, t := range s.Tombstones {
+		if time.Since(t.Removed) > TOMBSTONE_RETENTION {
+			delete(s.Tombstones, id)
+		}
+	}
+}
+
+// Subscribes chat `id` again with the configuration it had when it was removed.
+func (s *State) restoreChat(id int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pruneTombstones()
+	t := s.Tombstones[id]
+	if t == nil {
+		return fmt.Errorf("there is no removed chat %d from the last %d days", id, int(TOMBSTONE_RETENTION.Hours()/24))
+	}
+	chat := t.Chat
+	chat.UnreachableSince, chat.FailedAttempts, chat.MutedSince = nil, 0, nil
+	if list := s.BroadcastLists[chat.BroadcastList]; list != nil {
+		list.ChatIds = append(withoutChat(list.ChatIds, id), id)
+	} else {
+		chat.BroadcastList = ""
+	}
+	s.ChatIds[id] = chat
+	delete(s.Tombstones, id)
+	return nil
+}
+
+// Lists the chats which can be restored.
+func (s *State) tombstones() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pruneTombstones()
+	if len(s.Tombstones) == 0 {
+		return "There are no removed chats to restore."
+	}
+	var ids []int64
+	for id := range s.Tombstones {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return s.Tombstones[ids[i]].Removed.After(s.Tombstones[ids[j]].Removed) })
+	lines := []string{"Removed chats (use /restore <chat id>):"}
+	for _, id := range ids {
+		t := s.Tombstones[id]
+		lines = append(lines, fmt.Sprintf("%d: %s on %s", id, t.Reason, t.Removed.UTC().Format(time.RFC1123)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func restoreCommand(r *Request) string {
+	id := r.id
+	switch {
+	case len(r.args) == 0 && r.id == ADMIN_CHAT_ID:
+		return r.state.tombstones()
+	case len(r.args) == 0:
+		if !isAdmin(r.bot, r.message) {
+			return "Only admins can restore the settings of the chat."
+		}
+	case len(r.args) == 1 && ADMIN_CHAT_ID != 0 && r.id == ADMIN_CHAT_ID:
+		var err error
+		if id, err = strconv.ParseInt(r.args[0], 10, 64); err != nil {
+			return "Please specify a chat id"
+		}
+	default:
+		return "Please use /restore to restore the settings of this chat after an accidental /stop"
+	}
+	if err := r.state.restoreChat(id); err != nil {
+		return "Couldn't restore the chat: " + err.Error() + "."
+	}
+	return fmt.Sprintf("Chat %d is subscribed again with all its settings.", id)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Time for which newly appeared topics are marked in /topics.
+var NEW_TOPIC_PERIOD = 30 * 24 * time.Hour
+
+// Counts a discovered proposal with `topic` in the topic registry. Returns true if the topic
+// appeared for the first time; the first topics of an empty registry aren't considered new.
+func (s *State) recordTopic(topic string) bool {
+	if topic == "" || len(topic) > MAX_TOPIC_LENGTH {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	isNew := s.Topics[topic] == 0 && len(s.Topics) > 0
+	s.Topics[topic]++
+	if isNew {
+		s.TopicsFirstSeen[topic] = time.Now()
+	}
+	return isNew
+}
+
+// Returns true if `topic` appeared within the NEW_TOPIC_PERIOD.
+func (s *State) isNewTopic(topic string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	since, ok := s.TopicsFirstSeen[topic]
+	return ok && time.Since(since) < NEW_TOPIC_PERIOD
+}
+
+// Enables or disables the notices about new topics for chat `id`.
+func (s *State) setTopicAlerts(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.TopicAlerts = enabled
+	return true
+}
+
+func (s *State) topicAlertChatIds() (res []int64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for id, chat := range s.ChatIds {
+		if chat.TopicAlerts {
+			res = append(res, id)
+		}
+	}
+	return
+}
+
+// Tells the admin chat and the chats which opted in that the NNS introduced `topic`, along with how
+// to change their filters.
+func notifyNewTopic(shards *Shards, state *State, topic string) {
+	log.Println("A new topic appeared:", topic)
+	ids := state.topicAlertChatIds()
+	if ADMIN_CHAT_ID != 0 {
+		if chat, ok := state.chat(ADMIN_CHAT_ID); !ok || !chat.TopicAlerts {
+			ids = append(ids, ADMIN_CHAT_ID)
+		}
+	}
+	for _, id := range ids {
+		chat, _ := state.chat(id)
+		text := fmt.Sprintf("🆕 A new proposal topic #%s appeared. Use /block %s to opt out.", topic, topic)
+		if chat.FilterMode == FILTER_ONLY {
+			text = fmt.Sprintf("🆕 A new proposal topic #%s appeared. You only receive selected topics, "+
+				"so use /only to add it if you want to follow it.", topic)
+		}
+		send(shards, state, tgbotapi.NewMessage(id, text))
+	}
+}
+
+func topicAlertsCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /topic_alerts on or /topic_alerts off"
+	}
+	if !r.state.setTopicAlerts(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "You'll be notified when the NNS introduces a new proposal topic."
+	}
+	return "You won't be notified about new proposal topics anymore."
+}
+
+// Returns the topics ever seen, the most frequent first.
+func (s *State) seenTopics() (topics []string, counts map[string]int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	counts = map[string]int{}
+	for topic, count := range s.Topics {
+		topics = append(topics, topic)
+		counts[topic] = count
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if counts[topics[i]] == counts[topics[j]] {
+			return topics[i] < topics[j]
+		}
+		return counts[topics[i]] > counts[topics[j]]
+	})
+	return
+}
+
+func topicsCommand(r *Request) string {
+	topics, counts := r.state.seenTopics()
+	if len(topics) == 0 {
+		return "No proposals were seen yet."
+	}
+	lines := []string{"Topics of all proposals seen so far, to be used with /block or /only:"}
+	for _, topic := range topics {
+		line := fmt.Sprintf("#%s: %d", topic, counts[topic])
+		if r.state.isNewTopic(topic) {
+			line += " 🆕"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Periodically polls the status of all tracked proposals, announces edits and handles the
+// decided ones.
+func trackProposals(shards *Shards, state *State) {
+	ticker := time.NewTicker(STATUS_POLL_INTERVAL)
+	for range ticker.C {
+		for _, proposal := range state.trackedProposals() {
+			details, err := fetchProposal(proposal.Id)
+			if err != nil {
+				log.Println("Couldn't fetch the status of proposal", proposal.Id, ":", err)
+				continue
+			}
+			previous := proposal.Status
+			if details.Status != proposal.Status || len(proposal.History) == 0 {
+				proposal.History = append(proposal.History, StatusChange{details.Status, time.Now().UTC()})
+			}
+			proposal.Status, proposal.Deadline = details.Status, details.Deadline
+			proposal.Action, proposal.Tally = details.Action, &details.Tally
+			if edited := details.toProposal(); fingerprint(edited) != fingerprint(proposal) {
+				log.Println("Proposal", proposal.Id, "was edited")
+				notifyEdit(shards, state, proposal, edited)
+				proposal.Title, proposal.Summary = edited.Title, edited.Summary
+			}
+			state.cache(proposal)
+			// The feed doesn't contain the status, so the first observation of an open proposal is no change.
+			if previous != proposal.Status && (previous != "" || proposal.Status != STATUS_OPEN) {
+				events.publish(Event{Kind: STATUS_CHANGED, Proposal: proposal, Previous: previous})
+			}
+			if details.Status == STATUS_OPEN {
+				state.track(proposal)
+				continue
+			}
+			log.Println("Proposal", proposal.Id, "was decided:", details.Status)
+			state.recordBallots(proposal.Id, details.Ballots)
+			if proposal.Topic == TOPIC_GOVERNANCE {
+				notifyVoteBreakdown(shards, state, proposal, details)
+			}
+			state.unwatchEverywhere(proposal.Id)
+			unpinProposal(shards, state, proposal.Id)
+			state.untrack(proposal.Id)
+		}
+		trackSNSProposals(state)
+	}
+}
+
+// Polls the status of the tracked proposals of the other governance systems and publishes their
+// status changes.
+func trackSNSProposals(state *State) {
+	for _, proposal := range state.trackedSNSProposals() {
+		current, err := currentVersionOf(proposal)
+		if err != nil {
+			log.Println("Couldn't fetch the status of", proposal.SourceName, "proposal", proposal.Id, ":", err)
+			continue
+		}
+		previous := proposal.Status
+		proposal.Status = current.Status
+		if previous != proposal.Status {
+			events.publish(Event{Kind: STATUS_CHANGED, Proposal: proposal, Previous: previous})
+		}
+		if proposal.Status == STATUS_OPEN {
+			state.track(proposal)
+			continue
+		}
+		log.Println(proposal.SourceName, "proposal", proposal.Id, "was decided:", proposal.Status)
+		state.untrackSNS(proposal)
+	}
+}
+
+// Sends the votes of known neurons on a decided proposal to all chats which opted in.
+func notifyVoteBreakdown(shards *Shards, state *State, proposal Proposal, details apiProposal) {
+	ids := state.chatIdsForVoteBreakdown(proposal)
+	if len(ids) == 0 {
+		return
+	}
+	text := fmt.Sprintf("%s <b>Proposal %d was %s</b>\n%s\n\n%s\n\nKnown neuron votes:\n%s",
+		statusBadge(proposal), proposal.Id, details.Status, htmlTitle(proposal.Title), formatTally(details.Tally), formatBallots(details.Ballots))
+	for _, id := range ids {
+		msg := tgbotapi.NewMessage(id, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		if _, err := send(shards, state, msg); err == nil {
+			state.recordStatusUpdate(id, proposal)
+		}
+	}
+	log.Println("Sent the known neuron votes on proposal", proposal.Id, "to", len(ids), "users")
+}
+
+func formatTally(t tally) string {
+	if t.Total == 0 {
+		return "No votes recorded."
+	}
+	return fmt.Sprintf("Yes: %.2f%%, No: %.2f%%", 100*t.Yes/t.Total, 100*t.No/t.Total)
+}
+
+func formatBallots(ballots []ballot) string {
+	if len(ballots) == 0 {
+		return "No known neurons voted."
+	}
+	var lines []string
+	for _, b := range ballots {
+		switch b.Vote {
+		case VOTE_YES:
+			lines = append(lines, "👍 "+html.EscapeString(b.Name))
+		case VOTE_NO:
+			lines = append(lines, "👎 "+html.EscapeString(b.Name))
+		default:
+			lines = append(lines, "➖ "+html.EscapeString(b.Name)+" (didn't vote)")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
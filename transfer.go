@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// One-time code allowing to move or copy the configuration of a chat to another chat.
+type Transfer struct {
+	ChatId  int64     `json:"chat_id"`
+	Expires time.Time `json:"expires"`
+}
+
+// Creates a new transfer code for the configuration of chat `id`.
+func (s *State) createTransfer(id int64) (string, error) {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(buf)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ChatIds[id] == nil {
+		return "", fmt.Errorf("chat %d is not subscribed", id)
+	}
+	now := time.Now()
+	for code, t := range s.Transfers {
+		if now.After(t.Expires) {
+			delete(s.Transfers, code)
+		}
+	}
+	s.Transfers[code] = &Transfer{ChatId: id, Expires: now.Add(TRANSFER_CODE_TTL)}
+	return code, nil
+}
+
+var errSubscribed = fmt.Errorf("this chat has its own settings already")
+
+// Applies the configuration of the chat which created `code` to chat `id`. Unless `keep` is
+// set, the source chat is unsubscribed. The settings of a subscribed chat are only replaced, and
+// kept as a tombstone, if `replace` is set; otherwise errSubscribed is returned.
+func (s *State) redeemTransfer(id int64, code string, keep, replace bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	code = strings.ToUpper(code)
+	transfer := s.Transfers[code]
+	if transfer == nil || time.Now().After(transfer.Expires) {
+		return fmt.Errorf("the code is invalid or expired")
+	}
+	source := s.ChatIds[transfer.ChatId]
+	if source == nil {
+		return fmt.Errorf("the original chat is not subscribed anymore")
+	}
+	if transfer.ChatId == id {
+		return fmt.Errorf("the code must be redeemed in another chat")
+	}
+	if s.ChatIds[id] != nil && !replace {
+		return errSubscribed
+	}
+	// A round trip through JSON gives us a deep copy of all settings.
+	data, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+	var chat Chat
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return err
+	}
+	// Delivery state belongs to the original chat.
+	chat.Deferred = nil
+	chat.Deliveries = nil
+	chat.SettingsMessageId, chat.SettingsText = 0, ""
+	chat.MutedSince = nil
+	// Otherwise both chats would share the token of the original chat.
+	chat.APITokenHash = ""
+	// The bot of a private chat is recorded with its next update.
+	chat.Bot = ""
+	s.dropChat(id, "replaced by a transfer")
+	list := s.BroadcastLists[chat.BroadcastList]
+	if keep || list == nil {
+		// The operator manages the original chat only.
+		chat.BroadcastList = ""
+	}
+	s.ChatIds[id] = &chat
+	delete(s.Transfers, code)
+	if !keep {
+		s.dropChat(transfer.ChatId, "transferred")
+		if list != nil {
+			list.ChatIds = append(list.ChatIds, id)
+		}
+	}
+	log.Println("Transferred the configuration of", transfer.ChatId, "to", id, "(kept original:", keep, ")")
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const MAX_UNFURLED_PROPOSALS = 3
+
+// Links to proposals on the NNS dapp and on the dashboard; the id is the last submatch.
+var proposalLinkPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:nns\.ic0\.app|nns\.internetcomputer\.org)/proposal/?\?(?:\S*&)?proposal=(\d+)`),
+	regexp.MustCompile(`dashboard\.internetcomputer\.org/proposal/(\d+)`),
+}
+
+// Returns the ids of the proposals linked in `message`, including the links hidden behind text.
+func linkedProposals(message *tgbotapi.Message) (res []uint64) {
+	texts := []string{message.Text}
+	for _, entity := range message.Entities {
+		if entity.Type == "text_link" {
+			texts = append(texts, entity.URL)
+		}
+	}
+	seen := map[uint64]bool{}
+	for _, text := range texts {
+		for _, pattern := range proposalLinkPatterns {
+			for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+				id, err := strconv.ParseUint(m[len(m)-1], 10, 64)
+				if err != nil || seen[id] || len(res) == MAX_UNFURLED_PROPOSALS {
+					continue
+				}
+				seen[id] = true
+				res = append(res, id)
+			}
+		}
+	}
+	return
+}
+
+func (s *State) setPreviews(id int64, enabled bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return false
+	}
+	chat.NoPreviews = !enabled
+	return true
+}
+
+// Replies to a message linking proposals with the rendering of each proposal in the format of the
+// chat, in the background as the proposals may have to be fetched. Only subscribed chats get
+// previews, as only they can turn them off. Returns true if the message contained a proposal link.
+func unfurlLinks(shards *Shards, state *State, message *tgbotapi.Message) bool {
+	if message.From != nil && message.From.IsBot || strings.HasPrefix(message.Text, "/") {
+		return false
+	}
+	ids := linkedProposals(message)
+	if len(ids) == 0 {
+		return false
+	}
+	chat, ok := state.chat(message.Chat.ID)
+	if !ok {
+		return false
+	}
+	if !chat.NoPreviews {
+		go sendPreviews(shards, state, message, chat, ids)
+	}
+	return true
+}
+
+func sendPreviews(shards *Shards, state *State, message *tgbotapi.Message, chat Chat, ids []uint64) {
+	id := message.Chat.ID
+	for _, proposalId := range ids {
+		proposal, err := state.findProposal(proposalId)
+		if err != nil {
+			continue
+		}
+		msg := tgbotapi.NewMessage(id, renderProposal(proposal, chat))
+		msg.ParseMode = tgbotapi.ModeHTML
+		msg.DisableWebPagePreview = true
+		msg.ReplyToMessageID = message.MessageID
+		msg.AllowSendingWithoutReply = true
+		if markup, ok := notificationKeyboard(state, proposal, chat); ok {
+			msg.ReplyMarkup = markup
+		}
+		send(shards, state, msg)
+	}
+	log.Println("Sent the previews of", len(ids), "linked proposals to", id)
+}
+
+func previewsCommand(r *Request) string {
+	enabled, ok := parseSwitch(r.args)
+	if !ok {
+		return "Please use /previews on or /previews off"
+	}
+	if !r.state.setPreviews(r.id, enabled) {
+		return NOT_SUBSCRIBED
+	}
+	if enabled {
+		return "The bot will reply to links to proposals with a preview of the proposal."
+	}
+	return "The bot won't reply to links to proposals anymore."
+}
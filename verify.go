@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximal number of artifacts verified per proposal and of verification results kept in memory.
+const (
+	MAX_VERIFIED_ARTIFACTS = 3
+	MAX_VERIFICATIONS      = 200
+)
+
+var (
+	artifactURLPattern = regexp.MustCompile(`https?://[^\s()<>\[\]"']+\.(?:wasm\.gz|wasm|tar\.gz|tar\.zst|tgz|img)\b`)
+	sha256Pattern      = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`)
+)
+
+// Results of the artifact verifications by proposal id. Verifications download large files, so
+// they run one at a time outside of the event bus, and the notifications are edited once the
+// result is known.
+type Verifications struct {
+	results map[uint64]bool
+	// Verified proposals, oldest first, and the proposals being verified.
+	order   []uint64
+	running map[uint64]bool
+	lock    sync.Mutex
+	slot    chan struct{}
+}
+
+var verifications = Verifications{results: map[uint64]bool{}, running: map[uint64]bool{}, slot: make(chan struct{}, 1)}
+
+// Returns whether the artifacts of proposal `id` matched their hashes, if they were verified.
+func (v *Verifications) result(id uint64) (verified, ok bool) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	verified, ok = v.results[id]
+	return
+}
+
+// Returns false if proposal `id` is verified already or being verified.
+func (v *Verifications) start(id uint64) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if _, ok := v.results[id]; ok || v.running[id] {
+		return false
+	}
+	v.running[id] = true
+	return true
+}
+
+// Records the result of the verification of proposal `id`; `ok` is false if it had no result.
+func (v *Verifications) finish(id uint64, verified, ok bool) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	delete(v.running, id)
+	if !ok {
+		return
+	}
+	v.results[id] = verified
+	v.order = append(v.order, id)
+	if len(v.order) > MAX_VERIFICATIONS {
+		delete(v.results, v.order[0])
+		v.order = v.order[1:]
+	}
+}
+
+// Returns the annotation of the full notification about `proposal`. Only NNS proposals are verified.
+func artifactAnnotation(proposal Proposal) string {
+	if proposal.Source != "" {
+		return ""
+	}
+	verified, ok := verifications.result(proposal.Id)
+	switch {
+	case !ok:
+		return ""
+	case verified:
+		return "✅ Artifact hash verified."
+	}
+	return "❌ Artifact hash mismatch!"
+}
+
+// Returns the mark of `proposal` in lists like the digest.
+func artifactMark(proposal Proposal) string {
+	verified, ok := verifications.result(proposal.Id)
+	switch {
+	case !ok || proposal.Source != "":
+		return ""
+	case verified:
+		return " (artifact ✅)"
+	}
+	return " (artifact ❌)"
+}
+
+// Artifact URL in a summary and the hash stated for it.
+type artifact struct {
+	url, hash string
+}
+
+// Pairs the artifact URLs of `summary` with their hashes: the first hash between a URL and the
+// next one, or else the last hash between the previous URL and this one. URLs without a hash are
+// skipped.
+func findArtifacts(summary string) (res []artifact) {
+	locs := artifactURLPattern.FindAllStringIndex(summary, -1)
+	for i, loc := range locs {
+		prevEnd, nextStart := 0, len(summary)
+		if i > 0 {
+			prevEnd = locs[i-1][1]
+		}
+		if i+1 < len(locs) {
+			nextStart = locs[i+1][0]
+		}
+		hash := sha256Pattern.FindString(summary[loc[1]:nextStart])
+		if hash == "" {
+			if before := sha256Pattern.FindAllString(summary[prevEnd:loc[0]], -1); len(before) > 0 {
+				hash = before[len(before)-1]
+			}
+		}
+		if hash != "" {
+			res = append(res, artifact{summary[loc[0]:loc[1]], hash})
+		}
+		if len(res) == MAX_VERIFIED_ARTIFACTS {
+			break
+		}
+	}
+	return
+}
+
+// Downloads the artifacts referenced in the summary and checks each against the hash stated for
+// it. Returns false for `ok` if the proposal references no artifact or one couldn't be downloaded.
+func verifyArtifacts(summary string) (verified, ok bool) {
+	artifacts := findArtifacts(summary)
+	if len(artifacts) == 0 {
+		return false, false
+	}
+	verified = true
+	for _, a := range artifacts {
+		sum, err := downloadAndHash(a.url)
+		if err != nil {
+			log.Println("Couldn't verify the artifact", a.url, ":", err)
+			return false, false
+		}
+		if strings.EqualFold(a.hash, sum) {
+			log.Println("Artifact", a.url, "matches the hash", sum)
+		} else {
+			log.Println("Artifact", a.url, "has the hash", sum, "instead of", a.hash)
+			verified = false
+		}
+	}
+	return verified, true
+}
+
+// Verifies the artifacts of `proposal` and edits the notifications about it with the result. The
+// notifications queued before are delivered first, so they can be edited.
+func verifyAndAnnotate(shards *Shards, state *State, queue *Queue, proposal Proposal) {
+	if !verifications.start(proposal.Id) {
+		return
+	}
+	verifications.slot <- struct{}{}
+	verified, ok := verifyArtifacts(proposal.Summary)
+	<-verifications.slot
+	verifications.finish(proposal.Id, verified, ok)
+	if !ok {
+		return
+	}
+	for deadline := time.Now().Add(ARTIFACT_DOWNLOAD_TIMEOUT); queue.length() > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Second)
+	}
+	annotateNotifications(shards, state, proposal)
+}
+
+// Streams the artifact at `url` through SHA-256 and returns the hex digest. Downloads larger
+// than MAX_ARTIFACT_SIZE are aborted.
+func downloadAndHash(url string) (string, error) {
+	client := http.Client{Timeout: ARTIFACT_DOWNLOAD_TIMEOUT}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if resp.ContentLength > MAX_ARTIFACT_SIZE {
+		return "", fmt.Errorf("artifact exceeds %d bytes", MAX_ARTIFACT_SIZE)
+	}
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, io.LimitReader(resp.Body, MAX_ARTIFACT_SIZE+1))
+	if err != nil {
+		return "", err
+	}
+	if n > MAX_ARTIFACT_SIZE {
+		return "", fmt.Errorf("artifact exceeds %d bytes", MAX_ARTIFACT_SIZE)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
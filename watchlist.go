@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Adds proposal `proposalId` to the watchlist of chat `id`.
+func (s *State) watch(id int64, proposalId uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return fmt.Errorf("this chat is not subscribed")
+	}
+	for _, watched := range chat.Watchlist {
+		if watched == proposalId {
+			return nil
+		}
+	}
+	if len(chat.Watchlist) >= MAX_WATCHED_PROPOSALS {
+		return fmt.Errorf("the watchlist is limited to %d proposals", MAX_WATCHED_PROPOSALS)
+	}
+	chat.Watchlist = append(chat.Watchlist, proposalId)
+	return nil
+}
+
+// Removes proposal `proposalId` from the watchlist of chat `id`.
+func (s *State) unwatch(id int64, proposalId uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if chat := s.ChatIds[id]; chat != nil {
+		chat.Watchlist = withoutId(chat.Watchlist, proposalId)
+	}
+}
+
+// Removes the decided proposal `proposalId` from all watchlists.
+func (s *State) unwatchEverywhere(proposalId uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, chat := range s.ChatIds {
+		chat.Watchlist = withoutId(chat.Watchlist, proposalId)
+	}
+}
+
+func withoutId(ids []uint64, id uint64) (res []uint64) {
+	for _, i := range ids {
+		if i != id {
+			res = append(res, i)
+		}
+	}
+	return
+}
+
+// Returns the tracked state of the watched proposals of chat `id`, sorted by deadline.
+func (s *State) watchlist(id int64) (res []Proposal) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	chat := s.ChatIds[id]
+	if chat == nil {
+		return nil
+	}
+	for _, proposalId := range chat.Watchlist {
+		if p := s.Tracked[proposalId]; p != nil {
+			res = append(res, *p)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Deadline < res[j].Deadline })
+	return
+}
+
+// Parses the proposal id passed as the only argument; `#129734` is accepted as well.
+func proposalIdArgument(args []string) (uint64, bool) {
+	if len(args) != 1 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(args[0], "#"), 10, 64)
+	return id, err == nil
+}
+
+func watchCommand(r *Request) string {
+	if !r.isGroup {
+		return "Watchlists are only available in groups."
+	}
+	proposalId, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a proposal id"
+	}
+	details, err := fetchProposal(proposalId)
+	if err != nil {
+		log.Println("Couldn't fetch proposal", proposalId, ":", err)
+		return fmt.Sprintf("Couldn't find proposal %d.", proposalId)
+	}
+	if details.Status != STATUS_OPEN {
+		return fmt.Sprintf("Proposal %d was already decided: %s.", proposalId, details.Status)
+	}
+	if err := r.state.watch(r.id, proposalId); err != nil {
+		return "Couldn't watch the proposal: " + err.Error() + "."
+	}
+	// Decided proposals are removed from the watchlists by the tracker.
+	if _, tracked := r.state.trackedProposal(proposalId); !tracked {
+		r.state.track(details.toProposal())
+	}
+	return fmt.Sprintf("Proposal %d was added to the watchlist of this group.", proposalId)
+}
+
+func unwatchCommand(r *Request) string {
+	proposalId, ok := proposalIdArgument(r.args)
+	if !ok {
+		return "Please specify a proposal id"
+	}
+	r.state.unwatch(r.id, proposalId)
+	return fmt.Sprintf("Proposal %d was removed from the watchlist.", proposalId)
+}
+
+func watchlistCommand(r *Request) string {
+	proposals := r.state.watchlist(r.id)
+	if len(proposals) == 0 {
+		return "The watchlist is empty; use /watch <proposal id> to add proposals."
+	}
+	chat, _ := r.state.chat(r.id)
+	lines := []string{"Watched proposals:"}
+	for _, p := range proposals {
+		deadline := time.Unix(p.Deadline, 0)
+		lines = append(lines, fmt.Sprintf("%s %d: %s (⏳ %s, %s)\n%s", statusBadge(p), p.Id, shortTitle(p.Title),
+			formatCountdown(time.Until(deadline)), formatTime(deadline, chat), proposalURL(p.Id)))
+	}
+	return strings.Join(lines, "\n\n")
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Recurring weekly time window in which a chat wants to receive notifications. Start and End
+// are minutes after midnight UTC; a window with Start > End spans midnight.
+type DeliveryWindow struct {
+	Days  []time.Weekday `json:"days"`
+	Start int            `json:"start"`
+	End   int            `json:"end"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parses the arguments of the /window command, e.g. `08:00 20:00 weekdays`.
+func parseDeliveryWindow(args []string) (*DeliveryWindow, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("please specify the start and end time, e.g. /window 08:00 20:00 weekdays")
+	}
+	start, err := parseTimeOfDay(args[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTimeOfDay(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("the window must not be empty")
+	}
+	days := "daily"
+	if len(args) == 3 {
+		days = strings.ToLower(args[2])
+	}
+	w := &DeliveryWindow{Start: start, End: end}
+	switch days {
+	case "daily":
+		w.Days = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	case "weekdays":
+		w.Days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	case "weekends":
+		w.Days = []time.Weekday{time.Saturday, time.Sunday}
+	default:
+		for _, name := range strings.Split(days, ",") {
+			day, ok := weekdayNames[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown day %q, use daily, weekdays, weekends or e.g. mon,wed,fri", name)
+			}
+			w.Days = append(w.Days, day)
+		}
+	}
+	return w, nil
+}
+
+// Parses a time like `08:00` into minutes after midnight.
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse the time %q, please use the HH:MM format", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Returns true if notifications can be delivered at time `t`.
+func (w *DeliveryWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.Start > w.End && minute < w.End {
+		// The window started on the previous day.
+		day = (day + 6) % 7
+	}
+	dayMatches := false
+	for _, d := range w.Days {
+		dayMatches = dayMatches || d == day
+	}
+	if w.Start < w.End {
+		return dayMatches && minute >= w.Start && minute < w.End
+	}
+	return dayMatches && (minute >= w.Start || minute < w.End)
+}
+
+func (w *DeliveryWindow) String() string {
+	var days []string
+	for _, d := range w.Days {
+		days = append(days, d.String()[:3])
+	}
+	return fmt.Sprintf("%02d:%02d–%02d:%02d UTC on %s",
+		w.Start/60, w.Start%60, w.End/60, w.End%60, strings.Join(days, ", "))
+}
+
+// Daily period in minutes after midnight UTC in which a chat doesn't want to be notified; quiet
+// hours with Start > End span midnight.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Parses the arguments of the /quiet command, e.g. `23:00 07:00`.
+func parseQuietHours(args []string) (*QuietHours, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("please specify the start and end time, e.g. /quiet 23:00 07:00")
+	}
+	start, err := parseTimeOfDay(args[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTimeOfDay(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("the quiet hours must not be empty")
+	}
+	return &QuietHours{start, end}, nil
+}
+
+// Returns true if time `t` is within the quiet hours.
+func (q *QuietHours) contains(t time.Time) bool {
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start < q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+func (q *QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d–%02d:%02d UTC", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+// Periodically delivers the proposals accumulated outside of the delivery window or during the
+// quiet hours of a chat as one catch-up message once notifications are allowed again.
+func flushDeferred(shards *Shards, state *State) {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		for id, proposals := range state.takeDeferred(time.Now()) {
+			msg := tgbotapi.NewMessage(id, renderCatchUp(proposals))
+			msg.ParseMode = tgbotapi.ModeHTML
+			msg.DisableWebPagePreview = true
+			_, err := send(shards, state, msg)
+			for _, p := range proposals {
+				state.recordDelivery(id, p.Source, p.Id, 0, err)
+				if err == nil {
+					metrics.observeDelivery(p.Source, p.Id)
+				}
+			}
+			log.Println("Delivered", len(proposals), "deferred proposals to", id)
+		}
+	}
+}
+
+// Renders a list of proposals which arrived outside of the delivery window or while paused.
+func renderCatchUp(proposals []Proposal) string {
+	lines := []string{fmt.Sprintf("<b>%d proposals you missed:</b>", len(proposals))}
+	for _, p := range proposals {
+		lines = append(lines, fmt.Sprintf("%s %s%s (%s)\n%s", statusBadge(p), htmlTitle(p.Title), artifactMark(p), hashtags(p), htmlURL(p)))
+	}
+	return strings.Join(lines, "\n\n")
+}